@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// AccessoryType is the type of artifact accessory
+type AccessoryType string
+
+const (
+	AccessoryTypeCosignSignature   AccessoryType = "signature.cosign"
+	AccessoryTypeNotationSignature AccessoryType = "signature.notation"
+	AccessoryTypeSBOM              AccessoryType = "harbor.sbom"
+	AccessoryTypeNydus             AccessoryType = "accelerator.nydus"
+	AccessoryTypeSubject           AccessoryType = "subject.accessory" // generic OCI referrer
+)
+
+// Accessory same as harbor swagger 'Accessory'
+// An accessory is an artifact attached to a subject artifact,e.g. signature,sbom or attestation.
+type Accessory struct {
+	ID                    int64         `json:"id"`
+	ArtifactID            int64         `json:"artifact_id"` // the artifact id of the accessory itself
+	SubjectArtifactID     int64         `json:"subject_artifact_id"`
+	SubjectArtifactDigest string        `json:"subject_artifact_digest"`
+	SubjectArtifactRepo   string        `json:"subject_artifact_repo"`
+	Size                  int64         `json:"size"`
+	Digest                string        `json:"digest"`
+	Type                  AccessoryType `json:"type"`
+	Icon                  string        `json:"icon"`
+	CreationTime          time.Time     `json:"creation_time"`
+}
+
+type ListAccessoriesOptions struct {
+	CommonListOptions
+	// Types filters accessories by type,empty means all types
+	Types []AccessoryType
+}
+
+func (o *ListAccessoriesOptions) toQuery() url.Values {
+	if len(o.Types) == 0 {
+		return o.CommonListOptions.toQuery()
+	}
+	query := Query()
+	if o.Query != nil {
+		query.conditions = append(query.conditions, o.Query.conditions...)
+	}
+	types := make([]interface{}, 0, len(o.Types))
+	for _, t := range o.Types {
+		types = append(types, string(t))
+	}
+	query.In("type", types...)
+	options := o.CommonListOptions
+	options.Query = query
+	return options.toQuery()
+}
+
+// ListAccessories lists the accessories attached to the artifact
+// GET /projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/accessories
+func (c *Client) ListAccessories(ctx context.Context, project, repository, reference string, options ListAccessoriesOptions) ([]Accessory, error) {
+	path := fmt.Sprintf("/projects/%s/repositories/%s/artifacts/%s/accessories?%s", project, repository, reference, options.toQuery().Encode())
+	ret := []Accessory{}
+	if err := c.doRequest(ctx, http.MethodGet, path, nil, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
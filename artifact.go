@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"time"
 
@@ -23,6 +24,8 @@ type Artifact struct {
 	AdditionLinks map[string]AdditionLink             `json:"addition_links"` // the resource link for build history(image), values.yaml(chart), dependency(chart), etc
 	Labels        []Label                             `json:"labels"`
 	ScanOverview  map[string]vuln.NativeReportSummary `json:"scan_overview"`
+	SBOMOverview  *SBOMOverview                       `json:"sbom_overview,omitempty"`
+	Accessories   []Accessory                         `json:"accessories,omitempty"`
 }
 
 // AdditionLink is a link via that the addition can be fetched
@@ -43,24 +46,67 @@ type Tag struct {
 	Signed       bool      `json:"signed"`
 }
 
+// Vulnerabilities is the vulnerability reports of an artifact,key is the report mime type
 type Vulnerabilities map[string]vuln.Report
 
+// Items returns the vulnerabilities of all reports,de-duplicated by id,package and version.
+// Items are in the order of reports sorted by mime type.
+func (v Vulnerabilities) Items() []*vuln.VulnerabilityItem {
+	mimetypes := make([]string, 0, len(v))
+	for mimetype := range v {
+		mimetypes = append(mimetypes, mimetype)
+	}
+	sort.Strings(mimetypes)
+
+	items, seen := []*vuln.VulnerabilityItem{}, map[string]bool{}
+	for _, mimetype := range mimetypes {
+		for _, item := range v[mimetype].Vulnerabilities {
+			if item == nil || seen[item.Key()] {
+				continue
+			}
+			seen[item.Key()] = true
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// cvssScore returns the CVSS v3 score of the vulnerability,fallback to CVSS v2
+func cvssScore(item *vuln.VulnerabilityItem) (float64, bool) {
+	if item.CVSSDetails.ScoreV3 != nil {
+		return *item.CVSSDetails.ScoreV3, true
+	}
+	if item.CVSSDetails.ScoreV2 != nil {
+		return *item.CVSSDetails.ScoreV2, true
+	}
+	return 0, false
+}
+
 type GetArtifactOptions struct {
 	WithTag             bool
 	WithScanOverview    bool
 	WithLabel           bool
 	WithImmutableStatus bool
 	WithSignature       bool
+	WithSBOMOverview    bool
+	WithAccessory       bool
 }
 
 func (o GetArtifactOptions) toQuery() url.Values {
-	return url.Values{
+	values := url.Values{
 		"with_tag":              []string{strconv.FormatBool(o.WithTag)},
 		"with_scan_overview":    []string{strconv.FormatBool(o.WithScanOverview)},
 		"with_label":            []string{strconv.FormatBool(o.WithLabel)},
 		"with_signature":        []string{strconv.FormatBool(o.WithSignature)},
 		"with_immutable_status": []string{strconv.FormatBool(o.WithImmutableStatus)},
 	}
+	if o.WithSBOMOverview {
+		values.Set("with_sbom_overview", "true")
+	}
+	if o.WithAccessory {
+		values.Set("with_accessory", "true")
+	}
+	return values
 }
 
 type Addition string
@@ -71,6 +117,7 @@ const (
 	AdditionHelmReadme      = "readme.md"
 	AdditionDependencies    = "dependencies"
 	AdditionVulnerabilities = "vulnerabilities"
+	AdditionSBOM            = "sbom"
 )
 
 // GET /projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/additions/{addition}
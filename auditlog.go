@@ -3,7 +3,10 @@ package client
 import (
 	"context"
 	"fmt"
+	"math"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/goharbor/harbor/src/pkg/audit/model"
 )
@@ -12,6 +15,109 @@ import (
 func (c *Client) ListAuditLogs(ctx context.Context, options CommonListOptions) ([]model.AuditLog, error) {
 	path := fmt.Sprintf("/audit-logs?%s", options.toQuery().Encode())
 	ret := []model.AuditLog{}
-	err := c.doRequest(ctx, http.MethodPut, path, nil, &ret)
+	err := c.doRequest(ctx, http.MethodGet, path, nil, &ret)
 	return ret, err
 }
+
+// AuditOperation is the operation recorded in audit log
+type AuditOperation string
+
+const (
+	AuditOperationCreate AuditOperation = "create"
+	AuditOperationDelete AuditOperation = "delete"
+	AuditOperationPull   AuditOperation = "pull"
+)
+
+// PurgeAuditParameters the parameters of purge audit log job
+type PurgeAuditParameters struct {
+	// Retention keep the audit logs in the duration,it is rounded up to hours.
+	Retention time.Duration
+	// IncludeOperations the operations of audit logs to purge,empty means all operations.
+	IncludeOperations []AuditOperation
+	// DryRun only count the audit logs to purge,no actually deletion
+	DryRun bool
+}
+
+func (p PurgeAuditParameters) toMap() map[string]interface{} {
+	operations := p.IncludeOperations
+	if len(operations) == 0 {
+		operations = []AuditOperation{AuditOperationCreate, AuditOperationDelete, AuditOperationPull}
+	}
+	included := make([]string, 0, len(operations))
+	for _, operation := range operations {
+		included = append(included, string(operation))
+	}
+	return map[string]interface{}{
+		"audit_retention_hour": int(math.Ceil(p.Retention.Hours())),
+		"include_operations":   strings.Join(included, ","),
+		"dry_run":              p.DryRun,
+	}
+}
+
+// GET /system/purgeaudit/schedule
+func (c *Client) GetPurgeAuditSchedule(ctx context.Context) (ExecHistory, error) {
+	ret := ExecHistory{}
+	if err := c.doRequest(ctx, http.MethodGet, "/system/purgeaudit/schedule", nil, &ret); err != nil {
+		return ret, err
+	}
+	return ret, nil
+}
+
+// POST /system/purgeaudit/schedule
+func (c *Client) CreatePurgeAuditSchedule(ctx context.Context, schedule ScheduleObj, params PurgeAuditParameters) error {
+	body := Schedule{Schedule: &schedule, Parameters: params.toMap()}
+	return c.doRequest(ctx, http.MethodPost, "/system/purgeaudit/schedule", body, nil)
+}
+
+// PUT /system/purgeaudit/schedule
+// Use ScheduleTypeNone to remove the schedule.
+func (c *Client) UpdatePurgeAuditSchedule(ctx context.Context, schedule ScheduleObj, params PurgeAuditParameters) error {
+	body := Schedule{Schedule: &schedule, Parameters: params.toMap()}
+	return c.doRequest(ctx, http.MethodPut, "/system/purgeaudit/schedule", body, nil)
+}
+
+// TriggerPurgeAudit run purge audit log immediately and returns the id of purge execution.
+// POST /system/purgeaudit/schedule
+func (c *Client) TriggerPurgeAudit(ctx context.Context, params PurgeAuditParameters) (int64, error) {
+	body := Schedule{Schedule: &ScheduleObj{Type: ScheduleTypeManual}, Parameters: params.toMap()}
+	resp, err := c.doRequestWithResponse(ctx, http.MethodPost, "/system/purgeaudit/schedule", body, nil)
+	if err != nil {
+		return 0, err
+	}
+	// Location: /api/v2.0/system/purgeaudit/12
+	return idFromLocation(resp.Header.Get("Location"))
+}
+
+// GET /system/purgeaudit
+func (c *Client) ListPurgeAuditHistory(ctx context.Context, options CommonListOptions) ([]ExecHistory, error) {
+	path := fmt.Sprintf("/system/purgeaudit?%s", options.toQuery().Encode())
+	ret := []ExecHistory{}
+	if err := c.doRequest(ctx, http.MethodGet, path, nil, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// GET /system/purgeaudit/{purge_id}
+func (c *Client) GetPurgeAudit(ctx context.Context, id int64) (ExecHistory, error) {
+	path := fmt.Sprintf("/system/purgeaudit/%d", id)
+	ret := ExecHistory{}
+	if err := c.doRequest(ctx, http.MethodGet, path, nil, &ret); err != nil {
+		return ret, err
+	}
+	return ret, nil
+}
+
+// GET /system/purgeaudit/{purge_id}/log
+func (c *Client) GetPurgeAuditLog(ctx context.Context, id int64) ([]byte, error) {
+	path := fmt.Sprintf("/system/purgeaudit/%d/log", id)
+	log := []byte{}
+	err := c.doRequest(ctx, http.MethodGet, path, nil, &log)
+	return log, err
+}
+
+// PUT /system/purgeaudit/{purge_id}
+func (c *Client) StopPurgeAudit(ctx context.Context, id int64) error {
+	path := fmt.Sprintf("/system/purgeaudit/%d", id)
+	return c.doRequest(ctx, http.MethodPut, path, nil, nil)
+}
@@ -0,0 +1,187 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/goharbor/harbor/src/pkg/audit/model"
+)
+
+// AuditLogCursor is the position of the last audit log emitted by WatchAuditLogs
+type AuditLogCursor struct {
+	ID     int64     `json:"id"`
+	OpTime time.Time `json:"op_time"`
+}
+
+// AuditLogCursorStore persists AuditLogCursor,so a restarted watcher resumes without loss.
+type AuditLogCursorStore interface {
+	// Load returns the saved cursor,zero cursor if nothing saved.
+	Load(ctx context.Context) (AuditLogCursor, error)
+	Save(ctx context.Context, cursor AuditLogCursor) error
+}
+
+type WatchAuditLogsFilter struct {
+	Operation    string
+	ResourceType string
+	Resource     string
+	Username     string
+	// Since is the start time of audit logs when no cursor saved,zero means from the beginning.
+	Since time.Time
+	// Interval between two polls,default 10s.
+	Interval time.Duration
+	// PageSize default 100.
+	PageSize int
+	// Cursor store,optional.
+	Cursor AuditLogCursorStore
+}
+
+const (
+	defaultWatchAuditLogsInterval = 10 * time.Second
+	defaultWatchAuditLogsPageSize = 100
+)
+
+// WatchAuditLogs polls GET /audit-logs and emits new audit logs in op_time order.
+//
+// It remembers the id and op_time of the last emitted audit log and de-duplicates across polls.
+// The delivery is at-least-once: the cursor of an audit log is saved into filter.Cursor
+// when the consumer receives the next one,so the last received audit log is emitted again after restart.
+// Both channels are closed when ctx done or an error occurred,the error is sent on the error channel.
+func (c *Client) WatchAuditLogs(ctx context.Context, filter WatchAuditLogsFilter) (<-chan model.AuditLog, <-chan error) {
+	logs, errs := make(chan model.AuditLog), make(chan error, 1)
+	go func() {
+		defer close(logs)
+		defer close(errs)
+		if err := c.watchAuditLogs(ctx, filter, logs); err != nil && !errors.Is(err, context.Canceled) {
+			errs <- err
+		}
+	}()
+	return logs, errs
+}
+
+func (c *Client) watchAuditLogs(ctx context.Context, filter WatchAuditLogsFilter, into chan<- model.AuditLog) error {
+	if filter.Interval <= 0 {
+		filter.Interval = defaultWatchAuditLogsInterval
+	}
+	if filter.PageSize <= 0 {
+		filter.PageSize = defaultWatchAuditLogsPageSize
+	}
+	cursor := AuditLogCursor{OpTime: filter.Since}
+	if filter.Cursor != nil {
+		saved, err := filter.Cursor.Load(ctx)
+		if err != nil {
+			return fmt.Errorf("load audit log cursor: %w", err)
+		}
+		if saved.ID != 0 {
+			cursor = saved
+		}
+	}
+	return poll(ctx, filter.Interval, filter.Interval, func(ctx context.Context) (bool, error) {
+		auditlogs, err := c.listAuditLogsSince(ctx, filter, cursor)
+		if err != nil {
+			return false, err
+		}
+		for _, auditlog := range auditlogs {
+			// op_time range is inclusive and in seconds,skip the logs already emitted
+			if auditlog.ID <= cursor.ID {
+				continue
+			}
+			select {
+			case into <- auditlog:
+			case <-ctx.Done():
+				return false, ctx.Err()
+			}
+			// the consumer is back for the next one,the previous one is handled
+			if filter.Cursor != nil && cursor.ID != 0 {
+				if err := filter.Cursor.Save(ctx, cursor); err != nil {
+					return false, fmt.Errorf("save audit log cursor: %w", err)
+				}
+			}
+			cursor = AuditLogCursor{ID: auditlog.ID, OpTime: auditlog.OpTime}
+		}
+		return false, nil
+	})
+}
+
+// listAuditLogsSince list all pages of audit logs from cursor,sorted by op_time and id
+func (c *Client) listAuditLogsSince(ctx context.Context, filter WatchAuditLogsFilter, cursor AuditLogCursor) ([]model.AuditLog, error) {
+	query := Query()
+	if !cursor.OpTime.IsZero() {
+		query.Range("op_time", cursor.OpTime, nil)
+	}
+	for _, kv := range [][2]string{
+		{"operation", filter.Operation},
+		{"resource_type", filter.ResourceType},
+		{"resource", filter.Resource},
+		{"username", filter.Username},
+	} {
+		if kv[1] != "" {
+			query.Eq(kv[0], kv[1])
+		}
+	}
+
+	all := []model.AuditLog{}
+	for page := 1; ; page++ {
+		options := CommonListOptions{Page: page, Size: filter.PageSize, Query: query, Sort: "op_time"}
+		auditlogs, err := c.ListAuditLogs(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, auditlogs...)
+		if len(auditlogs) < filter.PageSize {
+			break
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].OpTime.Equal(all[j].OpTime) {
+			return all[i].ID < all[j].ID
+		}
+		return all[i].OpTime.Before(all[j].OpTime)
+	})
+	return all, nil
+}
+
+// FileAuditLogCursorStore saves AuditLogCursor as json into a file
+type FileAuditLogCursorStore struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func NewFileAuditLogCursorStore(path string) *FileAuditLogCursorStore {
+	return &FileAuditLogCursorStore{Path: path}
+}
+
+func (s *FileAuditLogCursorStore) Load(ctx context.Context) (AuditLogCursor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cursor := AuditLogCursor{}
+	content, err := os.ReadFile(s.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cursor, nil
+		}
+		return cursor, err
+	}
+	err = json.Unmarshal(content, &cursor)
+	return cursor, err
+}
+
+func (s *FileAuditLogCursorStore) Save(ctx context.Context, cursor AuditLogCursor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	content, err := json.Marshal(cursor)
+	if err != nil {
+		return err
+	}
+	// write then rename,avoid a broken cursor file on crash
+	tmp := s.Path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.Path)
+}
@@ -0,0 +1,184 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goharbor/harbor/src/pkg/audit/model"
+)
+
+// fakeAuditLogServer serves GET /api/v2.0/audit-logs with pagination,
+// 'q' is parsed as harbor does: 'op_time=[min~]' and exact match of other fields.
+type fakeAuditLogServer struct {
+	mu      sync.Mutex
+	logs    []model.AuditLog
+	queries []string // the raw 'q' received
+}
+
+func (s *fakeAuditLogServer) add(logs ...model.AuditLog) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logs = append(s.logs, logs...)
+}
+
+func (s *fakeAuditLogServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	query := r.URL.Query()
+	// harbor unescapes 'q' once more
+	q, _ := url.QueryUnescape(query.Get("q"))
+	s.queries = append(s.queries, q)
+	min := time.Time{}
+	exact := map[string]string{}
+	for _, condition := range strings.Split(q, ",") {
+		kv := strings.SplitN(condition, "=", 2)
+		switch {
+		case len(kv) != 2:
+			continue
+		case kv[0] == "op_time":
+			from := strings.TrimSuffix(strings.TrimPrefix(kv[1], "["), "~]")
+			t, err := time.Parse(queryTimeFormat, from)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			min = t
+		default:
+			exact[kv[0]] = kv[1]
+		}
+	}
+	matched := []model.AuditLog{}
+	for _, log := range s.logs {
+		fields := map[string]string{
+			"operation": log.Operation, "resource_type": log.ResourceType,
+			"resource": log.Resource, "username": log.Username,
+		}
+		ok := !log.OpTime.Before(min)
+		for k, v := range exact {
+			ok = ok && fields[k] == v
+		}
+		if ok {
+			matched = append(matched, log)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].OpTime.Before(matched[j].OpTime) })
+	page, _ := strconv.Atoi(query.Get("page"))
+	size, _ := strconv.Atoi(query.Get("page_size"))
+	start, end := (page-1)*size, page*size
+	if start > len(matched) {
+		start = len(matched)
+	}
+	if end > len(matched) {
+		end = len(matched)
+	}
+	_ = json.NewEncoder(w).Encode(matched[start:end])
+}
+
+func receiveAuditLogs(t *testing.T, logs <-chan model.AuditLog, n int) []int64 {
+	t.Helper()
+	ids := []int64{}
+	for len(ids) < n {
+		select {
+		case log := <-logs:
+			ids = append(ids, log.ID)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting audit logs, received %v", ids)
+		}
+	}
+	return ids
+}
+
+func TestWatchAuditLogs(t *testing.T) {
+	base := time.Date(2022, 2, 17, 4, 43, 9, 0, time.UTC)
+	fake := &fakeAuditLogServer{}
+	fake.add(
+		model.AuditLog{ID: 1, OpTime: base, Operation: "create"},
+		model.AuditLog{ID: 2, OpTime: base, Operation: "pull"},
+		model.AuditLog{ID: 3, OpTime: base.Add(time.Second), Operation: "pull"},
+	)
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	cli, _ := NewClient(server.URL)
+	store := NewFileAuditLogCursorStore(filepath.Join(t.TempDir(), "cursor.json"))
+	filter := WatchAuditLogsFilter{Interval: 10 * time.Millisecond, PageSize: 2, Cursor: store}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	logs, errs := cli.WatchAuditLogs(ctx, filter)
+	if got := receiveAuditLogs(t, logs, 3); !equalIDs(got, []int64{1, 2, 3}) {
+		t.Fatalf("first poll ids = %v", got)
+	}
+	// same second as the last emitted one
+	fake.add(model.AuditLog{ID: 4, OpTime: base.Add(time.Second)}, model.AuditLog{ID: 5, OpTime: base.Add(2 * time.Second)})
+	if got := receiveAuditLogs(t, logs, 2); !equalIDs(got, []int64{4, 5}) {
+		t.Fatalf("second poll ids = %v", got)
+	}
+	cancel()
+	for range logs {
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("watch error: %v", err)
+	}
+
+	// restart resumes from the saved cursor,the last received one is not acknowledged
+	cursor, err := store.Load(context.Background())
+	if err != nil || cursor.ID != 4 {
+		t.Fatalf("saved cursor = %+v, err = %v", cursor, err)
+	}
+	fake.add(model.AuditLog{ID: 6, OpTime: base.Add(2 * time.Second)})
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	logs, _ = cli.WatchAuditLogs(ctx, filter)
+	if got := receiveAuditLogs(t, logs, 2); !equalIDs(got, []int64{5, 6}) {
+		t.Fatalf("resumed ids = %v", got)
+	}
+}
+
+func TestWatchAuditLogs_Filter(t *testing.T) {
+	base := time.Date(2022, 2, 17, 4, 43, 9, 0, time.UTC)
+	fake := &fakeAuditLogServer{}
+	fake.add(
+		model.AuditLog{ID: 1, OpTime: base, Operation: "pull", Username: "admin"},
+		model.AuditLog{ID: 2, OpTime: base, Operation: "create", Username: "admin"},
+		model.AuditLog{ID: 3, OpTime: base.Add(time.Second), Operation: "pull", Username: "robot"},
+		model.AuditLog{ID: 4, OpTime: base.Add(time.Second), Operation: "pull", Username: "admin"},
+	)
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	cli, _ := NewClient(server.URL)
+	filter := WatchAuditLogsFilter{Interval: 10 * time.Millisecond, Operation: "pull", Username: "admin", Since: base}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	logs, _ := cli.WatchAuditLogs(ctx, filter)
+	if got := receiveAuditLogs(t, logs, 2); !equalIDs(got, []int64{1, 4}) {
+		t.Fatalf("filtered ids = %v", got)
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if want := "op_time=[2022-02-17T04:43:09~],operation=pull,username=admin"; fake.queries[0] != want {
+		t.Errorf("q = %s, want %s", fake.queries[0], want)
+	}
+}
+
+func equalIDs(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,504 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	imagespecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// DigestMismatchError is returned when the content does not match the expected digest
+type DigestMismatchError struct {
+	Expected digest.Digest
+	Actual   digest.Digest
+}
+
+func (e *DigestMismatchError) Error() string {
+	return fmt.Sprintf("digest mismatch: expected %s, got %s", e.Expected, e.Actual)
+}
+
+// BlobUpload is an upload session of a blob,
+// the session can be resumed by GetBlobUpload with its Location.
+type BlobUpload struct {
+	Name     string
+	Location string // the upload url,changes after each chunk uploaded
+	Offset   int64  // the size of content accepted by the registry
+	client   *OCIDistributionClient
+}
+
+// StartBlobUpload starts a blob upload session
+// end-4a	POST	/v2/<name>/blobs/uploads/	202	404
+func (c *OCIDistributionClient) StartBlobUpload(ctx context.Context, name string) (*BlobUpload, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Server+"/v2/"+name+"/blobs/uploads/", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return nil, fmt.Errorf("start blob upload of %s: no location returned", name)
+	}
+	return &BlobUpload{Name: name, Location: location, client: c}, nil
+}
+
+// MountBlob mounts the blob from repository fromRepo into targetRepo without transferring the content.
+// The registry may start an upload session instead of mounting,
+// e.g. the user can not pull fromRepo or mount is unsupported,
+// the blob is downloaded from fromRepo and uploaded to targetRepo then,mounted is false in this case.
+// end-11	POST	/v2/<name>/blobs/uploads/?mount=<digest>&from=<other_name>	201	404
+func (c *OCIDistributionClient) MountBlob(ctx context.Context, targetRepo string, dgst digest.Digest, fromRepo string) (mounted bool, err error) {
+	if err := dgst.Validate(); err != nil {
+		return false, err
+	}
+	query := url.Values{"mount": []string{dgst.String()}, "from": []string{fromRepo}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Server+"/v2/"+targetRepo+"/blobs/uploads/?"+query.Encode(), nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusCreated {
+		return true, nil
+	}
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return false, fmt.Errorf("mount blob %s to %s: no location returned", dgst, targetRepo)
+	}
+	upload := &BlobUpload{Name: targetRepo, Location: location, client: c}
+	blob, err := c.GetBlob(ctx, fromRepo, dgst)
+	if err != nil {
+		_ = upload.Cancel(ctx)
+		return false, err
+	}
+	defer blob.Close()
+	size := blob.Size
+	if size < 0 {
+		// the download responses without Content-Length,the upload requires the size
+		descriptor, err := c.HeadBlob(ctx, fromRepo, dgst)
+		if err != nil || descriptor.Size < 0 {
+			_ = upload.Cancel(ctx)
+			return false, fmt.Errorf("mount blob %s to %s: unknown blob size", dgst, targetRepo)
+		}
+		size = descriptor.Size
+	}
+	if err := upload.complete(ctx, dgst, io.LimitReader(blob, size), size); err != nil {
+		_ = upload.Cancel(ctx)
+		return false, err
+	}
+	return false, nil
+}
+
+// GetBlobUpload gets the status of an interrupted upload session to resume it
+// end-13	GET	/v2/<name>/blobs/uploads/<reference>	204	404
+func (c *OCIDistributionClient) GetBlobUpload(ctx context.Context, name, location string) (*BlobUpload, error) {
+	upload := &BlobUpload{Name: name, Location: location, client: c}
+	if err := upload.Status(ctx); err != nil {
+		return nil, err
+	}
+	return upload, nil
+}
+
+// PushBlob uploads the blob in a single request,
+// the upload is rejected if the content does not match dgst and size.
+// end-4a,end-6
+func (c *OCIDistributionClient) PushBlob(ctx context.Context, name string, dgst digest.Digest, size int64, content io.Reader) error {
+	if err := dgst.Validate(); err != nil {
+		return err
+	}
+	upload, err := c.StartBlobUpload(ctx, name)
+	if err != nil {
+		return err
+	}
+	verifier := &verifyingReader{reader: io.LimitReader(content, size), digester: dgst.Algorithm().Digester(), expected: dgst}
+	if err := upload.complete(ctx, dgst, verifier, size); err != nil {
+		_ = upload.Cancel(ctx)
+		if verifier.err != nil {
+			return verifier.err
+		}
+		return err
+	}
+	return nil
+}
+
+// PushBlobChunked uploads the blob in chunks of chunkSize.
+// The upload session is returned with the error,it can be resumed by BlobUpload.Upload
+// or after the process restarted by GetBlobUpload with its Location.
+func (c *OCIDistributionClient) PushBlobChunked(ctx context.Context, name string, dgst digest.Digest, content io.ReadSeeker, chunkSize int64) (*BlobUpload, error) {
+	if err := dgst.Validate(); err != nil {
+		return nil, err
+	}
+	upload, err := c.StartBlobUpload(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return upload, upload.Upload(ctx, dgst, content, chunkSize)
+}
+
+// Upload uploads the rest of content from the Offset in chunks and completes the upload.
+// content is the whole blob,the uploaded part is read again to verify the digest.
+// The upload is canceled if the content does not match dgst,and a *DigestMismatchError returned.
+func (u *BlobUpload) Upload(ctx context.Context, dgst digest.Digest, content io.ReadSeeker, chunkSize int64) error {
+	if chunkSize <= 0 {
+		return fmt.Errorf("invalid chunk size %d", chunkSize)
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	digester := dgst.Algorithm().Digester()
+	if _, err := io.CopyN(digester.Hash(), content, u.Offset); err != nil {
+		return fmt.Errorf("read uploaded content: %w", err)
+	}
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := io.ReadFull(content, buf)
+		if n > 0 {
+			digester.Hash().Write(buf[:n])
+			if err := u.Patch(ctx, buf[:n]); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if actual := digester.Digest(); actual != dgst {
+		_ = u.Cancel(ctx)
+		return &DigestMismatchError{Expected: dgst, Actual: actual}
+	}
+	return u.Complete(ctx, dgst, nil)
+}
+
+// Status refreshes the Offset and Location of the upload
+// end-13	GET	/v2/<name>/blobs/uploads/<reference>	204	404
+func (u *BlobUpload) Status(ctx context.Context) error {
+	resp, err := u.send(ctx, http.MethodGet, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return u.update(resp, 0)
+}
+
+// Patch uploads a chunk at the Offset
+// end-5	PATCH	/v2/<name>/blobs/uploads/<reference>	202	404/416
+func (u *BlobUpload) Patch(ctx context.Context, chunk []byte) error {
+	header := http.Header{}
+	header.Set("Content-Type", "application/octet-stream")
+	header.Set("Content-Range", fmt.Sprintf("%d-%d", u.Offset, u.Offset+int64(len(chunk))-1))
+	resp, err := u.send(ctx, http.MethodPatch, nil, header, bytes.NewReader(chunk))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return u.update(resp, u.Offset+int64(len(chunk)))
+}
+
+// Complete completes the upload with the last chunk,the last chunk may be empty.
+// end-6	PUT	/v2/<name>/blobs/uploads/<reference>?digest=<digest>	201	404/400
+func (u *BlobUpload) Complete(ctx context.Context, dgst digest.Digest, last []byte) error {
+	return u.complete(ctx, dgst, bytes.NewReader(last), int64(len(last)))
+}
+
+func (u *BlobUpload) complete(ctx context.Context, dgst digest.Digest, content io.Reader, size int64) error {
+	header := http.Header{}
+	header.Set("Content-Type", "application/octet-stream")
+	resp, err := u.send(ctx, http.MethodPut, url.Values{"digest": []string{dgst.String()}}, header, sizedReader{content, size})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	u.Offset += size
+	return nil
+}
+
+// Cancel cancels the upload session
+// DELETE /v2/<name>/blobs/uploads/<reference>	204	404
+func (u *BlobUpload) Cancel(ctx context.Context) error {
+	resp, err := u.send(ctx, http.MethodDelete, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (u *BlobUpload) send(ctx context.Context, method string, query url.Values, header http.Header, body io.Reader) (*http.Response, error) {
+	location, err := u.client.resolve(u.Location)
+	if err != nil {
+		return nil, err
+	}
+	if len(query) > 0 {
+		values := location.Query()
+		for k, v := range query {
+			values[k] = v
+		}
+		location.RawQuery = values.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, location.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if sized, ok := body.(sizedReader); ok {
+		req.ContentLength = sized.size
+		if sized.size == 0 {
+			req.Body = http.NoBody
+		}
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	return u.client.do(req)
+}
+
+// update the upload status from response,
+// expected is the offset used when the response has no range or an ambiguous range.
+func (u *BlobUpload) update(resp *http.Response, expected int64) error {
+	if location := resp.Header.Get("Location"); location != "" {
+		u.Location = location
+	}
+	u.Offset = expected
+	r := resp.Header.Get("Range")
+	// distribution reports an empty upload as "0-0" too
+	if r == "" || r == "0-0" {
+		return nil
+	}
+	end, err := strconv.ParseInt(strings.TrimPrefix(r, "0-"), 10, 64)
+	if err != nil || !strings.HasPrefix(r, "0-") {
+		return fmt.Errorf("invalid upload range %q", r)
+	}
+	u.Offset = end + 1
+	return nil
+}
+
+// resolve a location relative to the server
+func (c *OCIDistributionClient) resolve(location string) (*url.URL, error) {
+	base, err := url.Parse(c.Server)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+	return base.ResolveReference(ref), nil
+}
+
+// sizedReader is a request body of known size
+type sizedReader struct {
+	io.Reader
+	size int64
+}
+
+// verifyingReader fails the read at EOF if the content does not match the expected digest
+type verifyingReader struct {
+	reader   io.Reader
+	digester digest.Digester
+	expected digest.Digest
+	err      error
+}
+
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.digester.Hash().Write(p[:n])
+	if err == io.EOF {
+		if actual := r.digester.Digest(); actual != r.expected {
+			r.err = &DigestMismatchError{Expected: r.expected, Actual: actual}
+			return n, r.err
+		}
+	}
+	return n, err
+}
+
+// SizeMismatchError is returned when the size of content does not match the expected size
+type SizeMismatchError struct {
+	Expected int64
+	Actual   int64
+}
+
+func (e *SizeMismatchError) Error() string {
+	return fmt.Sprintf("size mismatch: expected %d, got %d", e.Expected, e.Actual)
+}
+
+// HeadBlob returns the digest and size of the blob
+// end-2	HEAD	/v2/<name>/blobs/<digest>	200	404
+func (c *OCIDistributionClient) HeadBlob(ctx context.Context, name string, dgst digest.Digest) (imagespecv1.Descriptor, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.Server+"/v2/"+name+"/blobs/"+dgst.String(), nil)
+	if err != nil {
+		return imagespecv1.Descriptor{}, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return imagespecv1.Descriptor{}, err
+	}
+	resp.Body.Close()
+	return imagespecv1.Descriptor{Digest: dgst, Size: resp.ContentLength}, nil
+}
+
+// GetBlob returns a stream of the blob,the stream must be closed by caller.
+// The digest and size are verified when the stream reaches EOF,
+// a *DigestMismatchError or *SizeMismatchError is returned instead of io.EOF on mismatch.
+// A broken download is resumed via HTTP Range from where it stopped.
+// end-2	GET	/v2/<name>/blobs/<digest>	200	404
+func (c *OCIDistributionClient) GetBlob(ctx context.Context, name string, dgst digest.Digest) (*BlobReader, error) {
+	if err := dgst.Validate(); err != nil {
+		return nil, err
+	}
+	body, size, err := c.openBlob(ctx, name, dgst, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &BlobReader{
+		Digest:     dgst,
+		Size:       size,
+		MaxResumes: 3,
+		ctx:        ctx,
+		client:     c,
+		name:       name,
+		body:       body,
+		digester:   dgst.Algorithm().Digester(),
+	}, nil
+}
+
+// GetBlobRange returns a stream of the blob content in [offset,offset+length),
+// a length not greater than zero means to the end of the blob.
+// The size of the content is verified,the digest can not be verified of partial content.
+func (c *OCIDistributionClient) GetBlobRange(ctx context.Context, name string, dgst digest.Digest, offset, length int64) (io.ReadCloser, error) {
+	if err := dgst.Validate(); err != nil {
+		return nil, err
+	}
+	body, size, err := c.openBlob(ctx, name, dgst, offset, length)
+	if err != nil {
+		return nil, err
+	}
+	if length <= 0 {
+		length = size
+	}
+	return &sizeVerifyingReader{ReadCloser: body, expected: length}, nil
+}
+
+// openBlob opens the blob from offset,the size of returned content is -1 if unknown.
+func (c *OCIDistributionClient) openBlob(ctx context.Context, name string, dgst digest.Digest, offset, length int64) (io.ReadCloser, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.Server+"/v2/"+name+"/blobs/"+dgst.String(), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	switch {
+	case length > 0:
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	case offset > 0:
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	if offset == 0 && length <= 0 || resp.StatusCode == http.StatusPartialContent {
+		return resp.Body, resp.ContentLength, nil
+	}
+	// the registry ignored the range and responses the whole blob
+	if _, err := io.CopyN(io.Discard, resp.Body, offset); err != nil {
+		resp.Body.Close()
+		return nil, 0, err
+	}
+	size := int64(-1)
+	if resp.ContentLength >= 0 {
+		size = resp.ContentLength - offset
+	}
+	if length > 0 {
+		return struct {
+			io.Reader
+			io.Closer
+		}{io.LimitReader(resp.Body, length), resp.Body}, length, nil
+	}
+	return resp.Body, size, nil
+}
+
+// BlobReader is a stream of blob verifies the digest and size at EOF
+type BlobReader struct {
+	Digest digest.Digest
+	Size   int64 // -1 if unknown
+	// MaxResumes is the max times to resume a broken download
+	MaxResumes int
+
+	ctx      context.Context
+	client   *OCIDistributionClient
+	name     string
+	body     io.ReadCloser
+	offset   int64
+	resumes  int
+	digester digest.Digester
+}
+
+func (r *BlobReader) Read(p []byte) (int, error) {
+	if r.body == nil {
+		return 0, errors.New("read on closed blob reader")
+	}
+	n, err := r.body.Read(p)
+	r.digester.Hash().Write(p[:n])
+	r.offset += int64(n)
+	switch {
+	case err == io.EOF:
+		if r.Size >= 0 && r.offset != r.Size {
+			return n, &SizeMismatchError{Expected: r.Size, Actual: r.offset}
+		}
+		if actual := r.digester.Digest(); actual != r.Digest {
+			return n, &DigestMismatchError{Expected: r.Digest, Actual: actual}
+		}
+		return n, io.EOF
+	case err != nil && r.resumes < r.MaxResumes && r.ctx.Err() == nil:
+		r.resumes++
+		r.body.Close()
+		body, _, openerr := r.client.openBlob(r.ctx, r.name, r.Digest, r.offset, 0)
+		if openerr != nil {
+			r.body = nil
+			return n, fmt.Errorf("resume blob at %d: %v: %w", r.offset, openerr, err)
+		}
+		r.body = body
+		return n, nil
+	default:
+		return n, err
+	}
+}
+
+func (r *BlobReader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
+
+// sizeVerifyingReader fails the read at EOF if the size of content does not match the expected size
+type sizeVerifyingReader struct {
+	io.ReadCloser
+	expected int64 // -1 if unknown
+	actual   int64
+}
+
+func (r *sizeVerifyingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.actual += int64(n)
+	if err == io.EOF && r.expected >= 0 && r.actual != r.expected {
+		return n, &SizeMismatchError{Expected: r.expected, Actual: r.actual}
+	}
+	return n, err
+}
@@ -0,0 +1,224 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+)
+
+func TestOCIDistributionClient_PushBlob(t *testing.T) {
+	registry, cli := newFakeRegistry(t)
+	ctx := context.Background()
+	content := []byte("hello world")
+	dgst := digest.FromBytes(content)
+
+	if err := cli.PushBlob(ctx, "library/app", dgst, int64(len(content)), bytes.NewReader(content)); err != nil {
+		t.Fatalf("PushBlob() error = %v", err)
+	}
+	if got := registry.blobs["library/app"][dgst]; !bytes.Equal(got, content) {
+		t.Errorf("stored blob = %q, want %q", got, content)
+	}
+
+	err := cli.PushBlob(ctx, "library/app", digest.FromString("other"), int64(len(content)), bytes.NewReader(content))
+	mismatch := &DigestMismatchError{}
+	if !errors.As(err, &mismatch) || mismatch.Actual != dgst {
+		t.Errorf("PushBlob() error = %v, want *DigestMismatchError", err)
+	}
+	if len(registry.uploads) != 0 {
+		t.Errorf("uploads not canceled: %d", len(registry.uploads))
+	}
+}
+
+func TestOCIDistributionClient_PushBlobChunked(t *testing.T) {
+	registry, cli := newFakeRegistry(t)
+	ctx := context.Background()
+	content := []byte(strings.Repeat("0123456789", 10))
+	dgst := digest.FromBytes(content)
+
+	upload, err := cli.PushBlobChunked(ctx, "library/app", dgst, bytes.NewReader(content), 30)
+	if err != nil {
+		t.Fatalf("PushBlobChunked() error = %v", err)
+	}
+	if upload.Offset != int64(len(content)) {
+		t.Errorf("Offset = %d, want %d", upload.Offset, len(content))
+	}
+	if got := registry.blobs["library/app"][dgst]; !bytes.Equal(got, content) {
+		t.Errorf("stored blob = %q, want %q", got, content)
+	}
+
+	_, err = cli.PushBlobChunked(ctx, "library/app", digest.FromString("other"), bytes.NewReader(content), 30)
+	if mismatch := (&DigestMismatchError{}); !errors.As(err, &mismatch) {
+		t.Errorf("PushBlobChunked() error = %v, want *DigestMismatchError", err)
+	}
+	if len(registry.uploads) != 0 {
+		t.Errorf("uploads not canceled: %d", len(registry.uploads))
+	}
+}
+
+func TestBlobUpload_Resume(t *testing.T) {
+	registry, cli := newFakeRegistry(t)
+	ctx := context.Background()
+	content := []byte(strings.Repeat("0123456789", 10))
+	dgst := digest.FromBytes(content)
+
+	// interrupted at the third chunk
+	registry.failPatchAt = 50
+	upload, err := cli.PushBlobChunked(ctx, "library/app", dgst, bytes.NewReader(content), 20)
+	if err == nil {
+		t.Fatal("PushBlobChunked() should fail")
+	}
+	registry.failPatchAt = 0
+
+	resumed, err := cli.GetBlobUpload(ctx, "library/app", upload.Location)
+	if err != nil {
+		t.Fatalf("GetBlobUpload() error = %v", err)
+	}
+	if resumed.Offset != 40 {
+		t.Errorf("Offset = %d, want 40", resumed.Offset)
+	}
+	if err := resumed.Upload(ctx, dgst, bytes.NewReader(content), 20); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if got := registry.blobs["library/app"][dgst]; !bytes.Equal(got, content) {
+		t.Errorf("stored blob = %q, want %q", got, content)
+	}
+}
+
+func TestBlobUpload_Cancel(t *testing.T) {
+	registry, cli := newFakeRegistry(t)
+	ctx := context.Background()
+	upload, err := cli.StartBlobUpload(ctx, "library/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := upload.Patch(ctx, []byte("part")); err != nil {
+		t.Fatal(err)
+	}
+	if err := upload.Cancel(ctx); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	if _, err := cli.GetBlobUpload(ctx, "library/app", upload.Location); err == nil {
+		t.Error("GetBlobUpload() of canceled upload should fail")
+	}
+	if len(registry.uploads) != 0 {
+		t.Errorf("uploads not canceled: %d", len(registry.uploads))
+	}
+}
+
+func TestOCIDistributionClient_GetBlob(t *testing.T) {
+	registry, cli := newFakeRegistry(t)
+	ctx := context.Background()
+	content := []byte(strings.Repeat("0123456789", 10))
+	dgst := registry.putBlob("library/app", content)
+
+	descriptor, err := cli.HeadBlob(ctx, "library/app", dgst)
+	if err != nil || descriptor.Size != int64(len(content)) || descriptor.Digest != dgst {
+		t.Errorf("HeadBlob() = %+v, %v", descriptor, err)
+	}
+	if _, err := cli.HeadBlob(ctx, "library/app", digest.FromString("unknown")); !IsOCINotFound(err) {
+		t.Errorf("HeadBlob() error = %v, want not found", err)
+	}
+
+	// resumed after the connection broken
+	registry.truncateBlobs = 42
+	blob, err := cli.GetBlob(ctx, "library/app", dgst)
+	if err != nil {
+		t.Fatalf("GetBlob() error = %v", err)
+	}
+	got, err := io.ReadAll(blob)
+	blob.Close()
+	if err != nil || !bytes.Equal(got, content) {
+		t.Errorf("GetBlob() = %q, %v", got, err)
+	}
+	registry.truncateBlobs = 0
+
+	part, err := cli.GetBlobRange(ctx, "library/app", dgst, 10, 5)
+	if err != nil {
+		t.Fatalf("GetBlobRange() error = %v", err)
+	}
+	got, err = io.ReadAll(part)
+	part.Close()
+	if err != nil || string(got) != "01234" {
+		t.Errorf("GetBlobRange() = %q, %v", got, err)
+	}
+
+	// content corrupted in storage
+	registry.blobs["library/app"][dgst] = []byte(strings.Repeat("9876543210", 10))
+	blob, _ = cli.GetBlob(ctx, "library/app", dgst)
+	defer blob.Close()
+	_, err = io.ReadAll(blob)
+	if mismatch := (&DigestMismatchError{}); !errors.As(err, &mismatch) || mismatch.Expected != dgst {
+		t.Errorf("GetBlob() error = %v, want *DigestMismatchError", err)
+	}
+}
+
+func TestOCIDistributionClient_GetBlob_Redirect(t *testing.T) {
+	registry, cli := newFakeRegistry(t)
+	cli.Auth = BasicAuth("user", "password")
+	content := []byte("stored in object storage")
+	dgst := registry.putBlob("library/app", content)
+
+	var leaked string
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked = r.Header.Get("Authorization")
+		_, _ = w.Write(content)
+	}))
+	defer storage.Close()
+	registry.redirectBlobs = storage.URL
+
+	blob, err := cli.GetBlob(context.Background(), "library/app", dgst)
+	if err != nil {
+		t.Fatalf("GetBlob() error = %v", err)
+	}
+	defer blob.Close()
+	if got, err := io.ReadAll(blob); err != nil || !bytes.Equal(got, content) {
+		t.Errorf("GetBlob() = %q, %v", got, err)
+	}
+	if leaked != "" {
+		t.Errorf("auth header leaked to storage: %s", leaked)
+	}
+}
+
+func TestOCIDistributionClient_MountBlob(t *testing.T) {
+	registry, cli := newFakeRegistry(t)
+	ctx := context.Background()
+	content := []byte("shared layer")
+	dgst := registry.putBlob("dev/app", content)
+
+	mounted, err := cli.MountBlob(ctx, "prod/app", dgst, "dev/app")
+	if err != nil || !mounted {
+		t.Fatalf("MountBlob() = %v, %v", mounted, err)
+	}
+	if got := registry.blobs["prod/app"][dgst]; !bytes.Equal(got, content) {
+		t.Errorf("mounted blob = %q, want %q", got, content)
+	}
+
+	// fallback to upload
+	registry.disableMount = true
+	mounted, err = cli.MountBlob(ctx, "staging/app", dgst, "dev/app")
+	if err != nil || mounted {
+		t.Fatalf("MountBlob() = %v, %v", mounted, err)
+	}
+	if got := registry.blobs["staging/app"][dgst]; !bytes.Equal(got, content) {
+		t.Errorf("uploaded blob = %q, want %q", got, content)
+	}
+	if len(registry.uploads) != 0 {
+		t.Errorf("uploads not completed: %d", len(registry.uploads))
+	}
+	// fallback to upload,the source download has no Content-Length
+	registry.chunkedBlobs = true
+	mounted, err = cli.MountBlob(ctx, "test/app", dgst, "dev/app")
+	if err != nil || mounted {
+		t.Fatalf("MountBlob() = %v, %v", mounted, err)
+	}
+	if got := registry.blobs["test/app"][dgst]; !bytes.Equal(got, content) {
+		t.Errorf("uploaded blob = %q, want %q", got, content)
+	}
+}
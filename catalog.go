@@ -0,0 +1,117 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Catalog lists all repositories of the registry,
+// it follows the Link header until the last page.
+// GET	/v2/_catalog
+func (c *OCIDistributionClient) Catalog(ctx context.Context) ([]string, error) {
+	return c.CatalogIterator(0).All(ctx)
+}
+
+// CatalogIterator returns an iterator over pages of repositories of size n,
+// n is decided by the registry if zero.
+// GET	/v2/_catalog?n=<integer>&last=<last repository from previous response>
+func (c *OCIDistributionClient) CatalogIterator(n int) *PageIterator {
+	return &PageIterator{client: c, next: "/v2/_catalog" + pageQuery(n, "")}
+}
+
+// TagIterator returns an iterator over pages of tags of the repository of size n,
+// n is decided by the registry if zero.
+// end-8b	GET	/v2/<name>/tags/list?n=<integer>&last=<last tag value from previous response>
+func (c *OCIDistributionClient) TagIterator(name string, n int) *PageIterator {
+	return &PageIterator{client: c, next: "/v2/" + name + "/tags/list" + pageQuery(n, "")}
+}
+
+// PageIterator walks the pages of a paginated list by the Link header,e.g.
+//
+//	it := cli.TagIterator("library/nginx", 100)
+//	for it.Next(ctx) {
+//		fmt.Println(it.Page())
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type PageIterator struct {
+	client *OCIDistributionClient
+	next   string // link of the next page,empty if no more pages
+	page   []string
+	err    error
+}
+
+// Next fetches the next page,it returns false when no more pages or an error occurred.
+func (it *PageIterator) Next(ctx context.Context) bool {
+	if it.err != nil || it.next == "" {
+		return false
+	}
+	it.page, it.next, it.err = it.client.listPage(ctx, it.next)
+	return it.err == nil
+}
+
+// Page returns the repositories or tags of the current page
+func (it *PageIterator) Page() []string {
+	return it.page
+}
+
+// Err returns the error occurred in Next
+func (it *PageIterator) Err() error {
+	return it.err
+}
+
+// All walks the remaining pages and returns all items
+func (it *PageIterator) All(ctx context.Context) ([]string, error) {
+	items := []string{}
+	for it.Next(ctx) {
+		items = append(items, it.Page()...)
+	}
+	return items, it.Err()
+}
+
+// listPage gets a page of catalog or tag list and the link of next page
+func (c *OCIDistributionClient) listPage(ctx context.Context, location string) ([]string, string, error) {
+	u, err := c.resolve(location)
+	if err != nil {
+		return nil, "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	list := struct {
+		Repositories []string `json:"repositories"`
+		Tags         []string `json:"tags"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, "", err
+	}
+	_, next := parsePagination(resp)
+	if list.Repositories != nil {
+		return list.Repositories, next, nil
+	}
+	return list.Tags, next, nil
+}
+
+func pageQuery(n int, last string) string {
+	query := url.Values{}
+	if n > 0 {
+		query.Set("n", strconv.Itoa(n))
+	}
+	if last != "" {
+		query.Set("last", last)
+	}
+	if len(query) == 0 {
+		return ""
+	}
+	return "?" + query.Encode()
+}
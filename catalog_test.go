@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"testing"
+)
+
+func TestOCIDistributionClient_Catalog(t *testing.T) {
+	registry, cli := newFakeRegistry(t)
+	ctx := context.Background()
+	for _, repository := range []string{"library/nginx", "library/app", "dev/app"} {
+		pushTestImage(registry, repository, "v1")
+	}
+
+	repositories, err := cli.Catalog(ctx)
+	if err != nil {
+		t.Fatalf("Catalog() error = %v", err)
+	}
+	if want := []string{"dev/app", "library/app", "library/nginx"}; !equalStrings(repositories, want) {
+		t.Errorf("Catalog() = %v, want %v", repositories, want)
+	}
+
+	pages := [][]string{}
+	it := cli.CatalogIterator(2)
+	for it.Next(ctx) {
+		pages = append(pages, it.Page())
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("CatalogIterator() error = %v", err)
+	}
+	if len(pages) != 2 || !equalStrings(pages[0], []string{"dev/app", "library/app"}) || !equalStrings(pages[1], []string{"library/nginx"}) {
+		t.Errorf("CatalogIterator() pages = %v", pages)
+	}
+}
+
+func TestOCIDistributionClient_TagIterator(t *testing.T) {
+	registry, cli := newFakeRegistry(t)
+	ctx := context.Background()
+	for _, tag := range []string{"v1", "v2", "v3", "latest"} {
+		pushTestImage(registry, "library/app", tag)
+	}
+
+	tags, err := cli.TagIterator("library/app", 1).All(ctx)
+	if err != nil {
+		t.Fatalf("TagIterator() error = %v", err)
+	}
+	if want := []string{"latest", "v1", "v2", "v3"}; !equalStrings(tags, want) {
+		t.Errorf("TagIterator() = %v, want %v", tags, want)
+	}
+
+	page, err := cli.ListTagsPaged(ctx, "library/app", 2, "v1")
+	if err != nil {
+		t.Fatalf("ListTagsPaged() error = %v", err)
+	}
+	if want := []string{"v2", "v3"}; !equalStrings(page.Tags, want) {
+		t.Errorf("ListTagsPaged() = %v, want %v", page.Tags, want)
+	}
+
+	if _, err := cli.TagIterator("library/unknown", 0).All(ctx); !IsOCINotFound(err) {
+		t.Errorf("TagIterator() error = %v, want not found", err)
+	}
+}
@@ -127,7 +127,9 @@ func (c *Client) doRequestWithResponse(ctx context.Context, method string, path
 		_, err := io.Copy(bytes.NewBuffer(into), resp.Body)
 		return resp, err
 	case *[]byte:
-		_, err := io.Copy(bytes.NewBuffer(*into), resp.Body)
+		buf := bytes.NewBuffer(*into)
+		_, err := io.Copy(buf, resp.Body)
+		*into = buf.Bytes()
 		return resp, err
 	case nil:
 	default:
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// ConfigurationItem is a item of GET /configurations response
+type ConfigurationItem struct {
+	Value    interface{} `json:"value"`
+	Editable bool        `json:"editable"`
+}
+
+// Configurations is the system configurations,key is the configuration name e.g. "auth_mode"
+type Configurations map[string]ConfigurationItem
+
+const (
+	ConfigurationAuditLogForwardEndpoint = "audit_log_forward_endpoint"
+	ConfigurationSkipAuditLogDatabase    = "skip_audit_log_database"
+)
+
+// GET /configurations
+func (c *Client) GetConfigurations(ctx context.Context) (Configurations, error) {
+	ret := Configurations{}
+	if err := c.doRequest(ctx, http.MethodGet, "/configurations", nil, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// PUT /configurations
+// only the configurations in body are updated.
+func (c *Client) UpdateConfigurations(ctx context.Context, configurations map[string]interface{}) error {
+	return c.doRequest(ctx, http.MethodPut, "/configurations", configurations, nil)
+}
+
+// AuditLogSettings the audit log forwarding settings in configurations
+type AuditLogSettings struct {
+	// ForwardEndpoint the syslog endpoint audit logs forward to, e.g. "tcp://syslog.example.com:514".
+	ForwardEndpoint string
+	// SkipAuditLogDatabase skip to write audit logs to database,
+	// it can only be enabled when ForwardEndpoint configured.
+	SkipAuditLogDatabase bool
+}
+
+func (c *Client) GetAuditLogSettings(ctx context.Context) (AuditLogSettings, error) {
+	settings := AuditLogSettings{}
+	configurations, err := c.GetConfigurations(ctx)
+	if err != nil {
+		return settings, err
+	}
+	if endpoint, ok := configurations[ConfigurationAuditLogForwardEndpoint].Value.(string); ok {
+		settings.ForwardEndpoint = endpoint
+	}
+	if skip, ok := configurations[ConfigurationSkipAuditLogDatabase].Value.(bool); ok {
+		settings.SkipAuditLogDatabase = skip
+	}
+	return settings, nil
+}
+
+func (c *Client) UpdateAuditLogSettings(ctx context.Context, settings AuditLogSettings) error {
+	return c.UpdateConfigurations(ctx, map[string]interface{}{
+		ConfigurationAuditLogForwardEndpoint: settings.ForwardEndpoint,
+		ConfigurationSkipAuditLogDatabase:    settings.SkipAuditLogDatabase,
+	})
+}
@@ -0,0 +1,269 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/opencontainers/go-digest"
+	imagespecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// ImageRef is an image in a registry
+type ImageRef struct {
+	Client    *OCIDistributionClient
+	Name      string // the repository,e.g. "library/nginx"
+	Reference string // tag or digest
+}
+
+// CopyStatus is the status of a blob or manifest reported in progress
+type CopyStatus string
+
+const (
+	CopyStatusExists       CopyStatus = "exists"       // the blob exists in destination,skipped
+	CopyStatusMounted      CopyStatus = "mounted"      // the blob is mounted from source repository
+	CopyStatusTransferring CopyStatus = "transferring" // Offset bytes of the blob are transferred
+	CopyStatusDone         CopyStatus = "done"         // the blob or manifest is copied
+)
+
+// CopyProgress is a progress event of CopyImage
+type CopyProgress struct {
+	Descriptor imagespecv1.Descriptor
+	Status     CopyStatus
+	Offset     int64 // bytes transferred
+}
+
+// CopyOptions are the options of CopyImage
+type CopyOptions struct {
+	// Referrers copies the referrers of manifests too,e.g. signatures and sboms.
+	Referrers bool
+	// Concurrency is the max number of blobs transferred at the same time,4 if zero.
+	Concurrency int
+	// Progress is called on progress of each blob and manifest,calls are serialized.
+	Progress func(CopyProgress)
+}
+
+// CopyImage copies the image from src to dst,including the config,layers and all platforms of an index.
+// Blobs exist in dst are skipped,and mounted from src repository if src and dst are the same registry.
+// The reference of dst is the reference of src if empty.
+// It returns the descriptor of the copied manifest.
+func CopyImage(ctx context.Context, src, dst ImageRef, options CopyOptions) (imagespecv1.Descriptor, error) {
+	if options.Concurrency <= 0 {
+		options.Concurrency = 4
+	}
+	if dst.Reference == "" {
+		dst.Reference = src.Reference
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	c := &imageCopier{
+		src:     src,
+		dst:     dst,
+		options: options,
+		sem:     make(chan struct{}, options.Concurrency),
+		copied:  map[digest.Digest]bool{},
+	}
+	return c.copyManifest(ctx, src.Reference, dst.Reference, nil)
+}
+
+type imageCopier struct {
+	src, dst ImageRef
+	options  CopyOptions
+	sem      chan struct{}
+	mu       sync.Mutex
+	copied   map[digest.Digest]bool // copied or copying blobs and manifests
+	// progressMu serializes the progress callback,apart from mu to not block the workers
+	progressMu sync.Mutex
+}
+
+// copyManifest copies the manifest and its children,blobs and referrers.
+// referrer is the descriptor in referrers list if the manifest is copied as a referrer.
+func (c *imageCopier) copyManifest(ctx context.Context, reference, target string, referrer *ReferrerDescriptor) (imagespecv1.Descriptor, error) {
+	manifest, err := c.src.Client.GetManifestRaw(ctx, c.src.Name, reference)
+	if err != nil {
+		return imagespecv1.Descriptor{}, fmt.Errorf("get manifest %s: %w", reference, err)
+	}
+	if target == "" {
+		target = manifest.Digest.String()
+	}
+	decoded, err := manifest.Decode()
+	if err != nil {
+		return imagespecv1.Descriptor{}, err
+	}
+	switch typed := decoded.(type) {
+	case *imagespecv1.Index:
+		for _, child := range typed.Manifests {
+			if _, err := c.copyManifest(ctx, child.Digest.String(), "", nil); err != nil {
+				return imagespecv1.Descriptor{}, err
+			}
+		}
+	case *imagespecv1.Manifest:
+		if err := c.copyBlobs(ctx, append([]imagespecv1.Descriptor{typed.Config}, typed.Layers...)); err != nil {
+			return imagespecv1.Descriptor{}, err
+		}
+	case *ArtifactManifest:
+		if err := c.copyBlobs(ctx, typed.Blobs); err != nil {
+			return imagespecv1.Descriptor{}, err
+		}
+	}
+
+	descriptor, header, err := c.dst.Client.putManifest(ctx, c.dst.Name, target, manifest.MediaType, manifest.Content)
+	if err != nil {
+		return descriptor, fmt.Errorf("put manifest %s: %w", target, err)
+	}
+	c.mark(descriptor.Digest)
+	c.report(CopyProgress{Descriptor: descriptor, Status: CopyStatusDone, Offset: descriptor.Size})
+	// the destination does not support the referrers api,maintain the referrers tag schema
+	if referrer != nil && header.Get("OCI-Subject") == "" {
+		subject := ReferrerManifest{}
+		if err := json.Unmarshal(manifest.Content, &subject); err != nil {
+			return descriptor, err
+		}
+		if subject.Subject != nil {
+			if err := c.dst.Client.addReferrerToTagIndex(ctx, c.dst.Name, subject.Subject.Digest, *referrer); err != nil {
+				return descriptor, err
+			}
+		}
+	}
+	if c.options.Referrers {
+		if err := c.copyReferrers(ctx, descriptor.Digest); err != nil {
+			return descriptor, err
+		}
+	}
+	return descriptor, nil
+}
+
+func (c *imageCopier) copyReferrers(ctx context.Context, subject digest.Digest) error {
+	referrers, err := c.src.Client.ListReferrers(ctx, c.src.Name, subject, "")
+	if err != nil {
+		return fmt.Errorf("list referrers of %s: %w", subject, err)
+	}
+	for i := range referrers {
+		if c.isCopied(referrers[i].Digest) {
+			continue
+		}
+		if _, err := c.copyManifest(ctx, referrers[i].Digest.String(), "", &referrers[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyBlobs copies blobs concurrently,the first error cancels the others
+func (c *imageCopier) copyBlobs(ctx context.Context, blobs []imagespecv1.Descriptor) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	wg := sync.WaitGroup{}
+	errs := make(chan error, len(blobs))
+	for _, blob := range blobs {
+		// foreign layers are not stored in the registry
+		if len(blob.URLs) > 0 || !c.mark(blob.Digest) {
+			continue
+		}
+		select {
+		case c.sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return firstError(errs, ctx.Err())
+		}
+		wg.Add(1)
+		go func(blob imagespecv1.Descriptor) {
+			defer func() { <-c.sem; wg.Done() }()
+			if err := c.copyBlob(ctx, blob); err != nil {
+				errs <- fmt.Errorf("copy blob %s: %w", blob.Digest, err)
+				cancel()
+			}
+		}(blob)
+	}
+	wg.Wait()
+	return firstError(errs, nil)
+}
+
+func (c *imageCopier) copyBlob(ctx context.Context, blob imagespecv1.Descriptor) error {
+	if _, err := c.dst.Client.HeadBlob(ctx, c.dst.Name, blob.Digest); err == nil {
+		c.report(CopyProgress{Descriptor: blob, Status: CopyStatusExists, Offset: blob.Size})
+		return nil
+	} else if !IsOCINotFound(err) {
+		return err
+	}
+	if c.src.Client.Server == c.dst.Client.Server && c.src.Name != c.dst.Name {
+		mounted, err := c.dst.Client.MountBlob(ctx, c.dst.Name, blob.Digest, c.src.Name)
+		if err != nil {
+			return err
+		}
+		status := CopyStatusDone
+		if mounted {
+			status = CopyStatusMounted
+		}
+		c.report(CopyProgress{Descriptor: blob, Status: status, Offset: blob.Size})
+		return nil
+	}
+	reader, err := c.src.Client.GetBlob(ctx, c.src.Name, blob.Digest)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	progress := &progressReader{Reader: reader, report: func(offset int64) {
+		c.report(CopyProgress{Descriptor: blob, Status: CopyStatusTransferring, Offset: offset})
+	}}
+	// reader.Size is unknown if the source responses without Content-Length
+	if err := c.dst.Client.PushBlob(ctx, c.dst.Name, blob.Digest, blob.Size, progress); err != nil {
+		return err
+	}
+	c.report(CopyProgress{Descriptor: blob, Status: CopyStatusDone, Offset: blob.Size})
+	return nil
+}
+
+// mark marks the digest copied,it returns false if marked already
+func (c *imageCopier) mark(dgst digest.Digest) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.copied[dgst] {
+		return false
+	}
+	c.copied[dgst] = true
+	return true
+}
+
+func (c *imageCopier) isCopied(dgst digest.Digest) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.copied[dgst]
+}
+
+func (c *imageCopier) report(progress CopyProgress) {
+	if c.options.Progress == nil {
+		return
+	}
+	c.progressMu.Lock()
+	defer c.progressMu.Unlock()
+	c.options.Progress(progress)
+}
+
+// progressReader reports the bytes read
+type progressReader struct {
+	io.Reader
+	offset int64
+	report func(offset int64)
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		r.offset += int64(n)
+		r.report(r.offset)
+	}
+	return n, err
+}
+
+// firstError returns the first error in errs,or fallback if none
+func firstError(errs chan error, fallback error) error {
+	select {
+	case err := <-errs:
+		return err
+	default:
+		return fallback
+	}
+}
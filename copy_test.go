@@ -0,0 +1,143 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	imagespecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// pushTestIndex pushes an image index of the images of tags
+func pushTestIndex(registry *fakeRegistry, repository, tag string, platforms map[string]imagespecv1.Platform) digest.Digest {
+	index := imagespecv1.Index{Versioned: specs.Versioned{SchemaVersion: 2}}
+	for imageTag, platform := range platforms {
+		platform := platform
+		dgst := pushTestImage(registry, repository, imageTag)
+		size := int64(len(registry.manifests[repository][dgst.String()].content))
+		index.Manifests = append(index.Manifests, imagespecv1.Descriptor{
+			MediaType: imagespecv1.MediaTypeImageManifest, Digest: dgst, Size: size, Platform: &platform,
+		})
+	}
+	return registry.putManifest(repository, imagespecv1.MediaTypeImageIndex, index, tag)
+}
+
+func TestCopyImage(t *testing.T) {
+	srcRegistry, srcClient := newFakeRegistry(t)
+	dstRegistry, dstClient := newFakeRegistry(t)
+	srcRegistry.referrers = true
+	// the source responses blobs without Content-Length
+	srcRegistry.chunkedBlobs = true
+	ctx := context.Background()
+
+	index := pushTestIndex(srcRegistry, "library/app", "v1", map[string]imagespecv1.Platform{
+		"amd64": {OS: "linux", Architecture: "amd64"},
+		"arm64": {OS: "linux", Architecture: "arm64"},
+	})
+	subject := imagespecv1.Descriptor{MediaType: imagespecv1.MediaTypeImageIndex, Digest: index, Size: 1}
+	sbom, err := srcClient.PushReferrer(ctx, "library/app", subject, "application/spdx+json", []ArtifactBlob{
+		{MediaType: "application/spdx+json", Content: []byte(`{}`)},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the config is shared by all images
+	existing := dstRegistry.putBlob("mirror/app", []byte(`{"architecture":"amd64","os":"linux"}`))
+
+	mu := sync.Mutex{}
+	statuses := map[digest.Digest]CopyStatus{}
+	descriptor, err := CopyImage(ctx,
+		ImageRef{Client: srcClient, Name: "library/app", Reference: "v1"},
+		ImageRef{Client: dstClient, Name: "mirror/app"},
+		CopyOptions{Referrers: true, Concurrency: 2, Progress: func(progress CopyProgress) {
+			mu.Lock()
+			defer mu.Unlock()
+			statuses[progress.Descriptor.Digest] = progress.Status
+		}},
+	)
+	if err != nil {
+		t.Fatalf("CopyImage() error = %v", err)
+	}
+	if descriptor.Digest != index {
+		t.Errorf("CopyImage() digest = %s, want %s", descriptor.Digest, index)
+	}
+	if _, ok := dstRegistry.manifests["mirror/app"]["v1"]; !ok {
+		t.Error("tag v1 not copied")
+	}
+	for dgst, content := range srcRegistry.blobs["library/app"] {
+		if got := dstRegistry.blobs["mirror/app"][dgst]; !bytes.Equal(got, content) {
+			t.Errorf("blob %s = %q, want %q", dgst, got, content)
+		}
+	}
+	if statuses[existing] != CopyStatusExists {
+		t.Errorf("status of existing blob = %q", statuses[existing])
+	}
+	if statuses[index] != CopyStatusDone {
+		t.Errorf("status of index = %q", statuses[index])
+	}
+
+	// the destination does not support the referrers api
+	referrers, err := dstClient.ListReferrers(ctx, "mirror/app", index, "")
+	if err != nil {
+		t.Fatalf("ListReferrers() error = %v", err)
+	}
+	if len(referrers) != 1 || referrers[0].Digest != sbom.Digest {
+		t.Errorf("copied referrers = %+v", referrers)
+	}
+}
+
+func TestCopyImage_Mount(t *testing.T) {
+	registry, cli := newFakeRegistry(t)
+	ctx := context.Background()
+	image := pushTestImage(registry, "dev/app", "v1")
+
+	statuses := []CopyStatus{}
+	_, err := CopyImage(ctx,
+		ImageRef{Client: cli, Name: "dev/app", Reference: image.String()},
+		ImageRef{Client: cli, Name: "prod/app", Reference: "v1"},
+		CopyOptions{Progress: func(progress CopyProgress) { statuses = append(statuses, progress.Status) }},
+	)
+	if err != nil {
+		t.Fatalf("CopyImage() error = %v", err)
+	}
+	if registry.manifests["prod/app"]["v1"].content == nil {
+		t.Error("tag v1 not copied")
+	}
+	mounted := 0
+	for _, status := range statuses {
+		if status == CopyStatusMounted {
+			mounted++
+		}
+	}
+	if mounted != 2 {
+		t.Errorf("mounted blobs = %d, want 2: %v", mounted, statuses)
+	}
+}
+
+func TestCopyImage_TokenAuth(t *testing.T) {
+	srcRegistry, srcTokens := newTokenFakeRegistry(t, "reader", "secret")
+	dstRegistry, dstTokens := newTokenFakeRegistry(t, "writer", "secret")
+	image := pushTestImage(srcRegistry, "library/app", "v1")
+	srcClient, _ := NewOCIDistributionClient(srcTokens.url, BasicAuth("reader", "secret"))
+	dstClient, _ := NewOCIDistributionClient(dstTokens.url, BasicAuth("writer", "secret"))
+
+	_, err := CopyImage(context.Background(),
+		ImageRef{Client: srcClient, Name: "library/app", Reference: "v1"},
+		ImageRef{Client: dstClient, Name: "mirror/app"},
+		CopyOptions{},
+	)
+	if err != nil {
+		t.Fatalf("CopyImage() error = %v", err)
+	}
+	if got := dstRegistry.manifests["mirror/app"]["v1"].content; !bytes.Equal(got, srcRegistry.manifests["library/app"][image.String()].content) {
+		t.Errorf("copied manifest = %s", got)
+	}
+	for dgst, content := range srcRegistry.blobs["library/app"] {
+		if got := dstRegistry.blobs["mirror/app"][dgst]; !bytes.Equal(got, content) {
+			t.Errorf("blob %s = %q, want %q", dgst, got, content)
+		}
+	}
+}
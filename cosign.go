@@ -0,0 +1,218 @@
+package client
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	distributionspecsv1 "github.com/opencontainers/distribution-spec/specs-go/v1"
+	"github.com/opencontainers/go-digest"
+	imagespecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	CosignSimpleSigningMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+	CosignSignatureAnnotation    = "dev.cosignproject.cosign/signature"
+	CosignSignatureType          = "cosign container image signature"
+)
+
+// ErrCosignVerification is returned when no valid signature found for the image
+var ErrCosignVerification = errors.New("no valid cosign signature")
+
+// SimpleSigningPayload is the payload signed by cosign,
+// see: https://github.com/containers/image/blob/main/docs/containers-signature.5.md
+type SimpleSigningPayload struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+	Optional map[string]interface{} `json:"optional,omitempty"`
+}
+
+// CosignSignature is a verified signature of an image
+type CosignSignature struct {
+	Digest    string // digest of the signature layer
+	Payload   SimpleSigningPayload
+	Signature []byte
+}
+
+// CosignVerifier verifies cosign signatures of images offline with a public key,
+// the signatures are fetched via the OCI distribution api.
+type CosignVerifier struct {
+	Client    *OCIDistributionClient
+	PublicKey crypto.PublicKey // *ecdsa.PublicKey or ed25519.PublicKey
+}
+
+// NewCosignVerifier creates a verifier with a PEM encoded public key,e.g. the content of cosign.pub
+func NewCosignVerifier(cli *OCIDistributionClient, publicKeyPEM []byte) (*CosignVerifier, error) {
+	key, err := ParsePublicKeyPEM(publicKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return &CosignVerifier{Client: cli, PublicKey: key}, nil
+}
+
+// ParsePublicKeyPEM parses a PKIX ECDSA or ed25519 public key in PEM format
+func ParsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found in public key")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	switch key.(type) {
+	case *ecdsa.PublicKey, ed25519.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", key)
+	}
+}
+
+// CosignSignatureTag returns the tag cosign stores signatures of the image,
+// e.g. "sha256-<hex>.sig" for "sha256:<hex>".
+func CosignSignatureTag(imageDigest string) (string, error) {
+	dgst, err := digest.Parse(imageDigest)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s-%s.sig", dgst.Algorithm(), dgst.Encoded()), nil
+}
+
+// Verify verifies the signatures of image 'name@imageDigest' stored in the cosign signature tag,
+// it returns the verified signatures or an error wraps ErrCosignVerification if none valid.
+func (v *CosignVerifier) Verify(ctx context.Context, name, imageDigest string) ([]CosignSignature, error) {
+	tag, err := CosignSignatureTag(imageDigest)
+	if err != nil {
+		return nil, err
+	}
+	return v.VerifySignatureManifest(ctx, name, tag, imageDigest)
+}
+
+// VerifyAccessories verifies the cosign signature accessories of the image,
+// accessories are listed by Client.ListAccessories,accessories of other types are ignored.
+func (v *CosignVerifier) VerifyAccessories(ctx context.Context, name, imageDigest string, accessories []Accessory) ([]CosignSignature, error) {
+	verified, reasons := []CosignSignature{}, []string{}
+	for _, accessory := range accessories {
+		if accessory.Type != AccessoryTypeCosignSignature {
+			continue
+		}
+		signatures, err := v.VerifySignatureManifest(ctx, name, accessory.Digest, imageDigest)
+		if err != nil {
+			reasons = append(reasons, err.Error())
+			continue
+		}
+		verified = append(verified, signatures...)
+	}
+	if len(verified) == 0 {
+		return nil, verificationError(reasons)
+	}
+	return verified, nil
+}
+
+// VerifySignatureManifest verifies the signatures in signature manifest 'name:signatureReference' for the image.
+// A missing signature manifest means the image is not signed,the error wraps ErrCosignVerification too.
+func (v *CosignVerifier) VerifySignatureManifest(ctx context.Context, name, signatureReference, imageDigest string) ([]CosignSignature, error) {
+	manifest := &imagespecv1.Manifest{}
+	if err := v.Client.getManifestJSON(ctx, name, signatureReference, manifest); err != nil {
+		if isManifestUnknown(err) {
+			return nil, fmt.Errorf("%w: signature manifest %s:%s not found", ErrCosignVerification, name, signatureReference)
+		}
+		return nil, fmt.Errorf("get signature manifest %s:%s: %w", name, signatureReference, err)
+	}
+	verified, reasons := []CosignSignature{}, []string{}
+	for _, layer := range manifest.Layers {
+		if layer.MediaType != CosignSimpleSigningMediaType {
+			continue
+		}
+		signature, err := v.verifyLayer(ctx, name, layer, imageDigest)
+		if err != nil {
+			reasons = append(reasons, fmt.Sprintf("layer %s: %v", layer.Digest, err))
+			continue
+		}
+		verified = append(verified, *signature)
+	}
+	if len(verified) == 0 {
+		return nil, verificationError(reasons)
+	}
+	return verified, nil
+}
+
+func (v *CosignVerifier) verifyLayer(ctx context.Context, name string, layer imagespecv1.Descriptor, imageDigest string) (*CosignSignature, error) {
+	signature, err := base64.StdEncoding.DecodeString(layer.Annotations[CosignSignatureAnnotation])
+	if err != nil || len(signature) == 0 {
+		return nil, errors.New("missing signature annotation")
+	}
+	payload, err := v.Client.getBlobBytes(ctx, name, layer.Digest)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.VerifyPayload(payload, signature); err != nil {
+		return nil, err
+	}
+	ret := &CosignSignature{Digest: layer.Digest.String(), Signature: signature}
+	if err := json.Unmarshal(payload, &ret.Payload); err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+	if ret.Payload.Critical.Type != CosignSignatureType {
+		return nil, fmt.Errorf("unexpected payload type %q", ret.Payload.Critical.Type)
+	}
+	if got := ret.Payload.Critical.Image.DockerManifestDigest; got != imageDigest {
+		return nil, fmt.Errorf("payload digest %s mismatch image digest %s", got, imageDigest)
+	}
+	return ret, nil
+}
+
+// VerifyPayload checks the signature of payload with the public key
+func (v *CosignVerifier) VerifyPayload(payload, signature []byte) error {
+	switch key := v.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		sum := sha256.Sum256(payload)
+		if !ecdsa.VerifyASN1(key, sum[:], signature) {
+			return errors.New("invalid ecdsa signature")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, payload, signature) {
+			return errors.New("invalid ed25519 signature")
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", v.PublicKey)
+	}
+	return nil
+}
+
+// isManifestUnknown reports whether err is a MANIFEST_UNKNOWN or NAME_UNKNOWN error of the registry
+func isManifestUnknown(err error) bool {
+	errresp := &distributionspecsv1.ErrorResponse{}
+	if !errors.As(err, &errresp) {
+		return false
+	}
+	for _, info := range errresp.Errors {
+		if info.Code == "MANIFEST_UNKNOWN" || info.Code == "NAME_UNKNOWN" {
+			return true
+		}
+	}
+	return false
+}
+
+func verificationError(reasons []string) error {
+	if len(reasons) == 0 {
+		return ErrCosignVerification
+	}
+	return fmt.Errorf("%w: %s", ErrCosignVerification, strings.Join(reasons, "; "))
+}
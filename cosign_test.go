@@ -0,0 +1,138 @@
+package client
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	imagespecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// pushTestImage pushes a minimal image and returns its manifest digest
+func pushTestImage(registry *fakeRegistry, repository, tag string) digest.Digest {
+	config := registry.putBlob(repository, []byte(`{"architecture":"amd64","os":"linux"}`))
+	content := []byte("layer of " + tag)
+	layer := registry.putBlob(repository, content)
+	return registry.putManifest(repository, imagespecv1.MediaTypeImageManifest, imagespecv1.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		Config:    imagespecv1.Descriptor{MediaType: imagespecv1.MediaTypeImageConfig, Digest: config, Size: 37},
+		Layers:    []imagespecv1.Descriptor{{MediaType: imagespecv1.MediaTypeImageLayer, Digest: layer, Size: int64(len(content))}},
+	}, tag)
+}
+
+// pushTestSignature signs the image digest with signer and pushes the cosign signature manifest
+func pushTestSignature(t *testing.T, registry *fakeRegistry, repository string, imageDigest digest.Digest, signer crypto.Signer) digest.Digest {
+	payload := SimpleSigningPayload{}
+	payload.Critical.Identity.DockerReference = "registry.example.com/" + repository
+	payload.Critical.Image.DockerManifestDigest = imageDigest.String()
+	payload.Critical.Type = CosignSignatureType
+	content, _ := json.Marshal(payload)
+
+	var signature []byte
+	var err error
+	switch key := signer.(type) {
+	case ed25519.PrivateKey:
+		signature = ed25519.Sign(key, content)
+	default:
+		sum := sha256.Sum256(content)
+		signature, err = signer.Sign(rand.Reader, sum[:], crypto.SHA256)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	layer := registry.putBlob(repository, content)
+	tag, _ := CosignSignatureTag(imageDigest.String())
+	return registry.putManifest(repository, imagespecv1.MediaTypeImageManifest, imagespecv1.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		Layers: []imagespecv1.Descriptor{{
+			MediaType:   CosignSimpleSigningMediaType,
+			Digest:      layer,
+			Size:        int64(len(content)),
+			Annotations: map[string]string{CosignSignatureAnnotation: base64.StdEncoding.EncodeToString(signature)},
+		}},
+	}, tag)
+}
+
+func publicKeyPEM(t *testing.T, signer crypto.Signer) []byte {
+	der, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func TestCosignVerifier_Verify(t *testing.T) {
+	ecdsaKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, ed25519Key, _ := ed25519.GenerateKey(rand.Reader)
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	for name, signer := range map[string]crypto.Signer{"ecdsa": ecdsaKey, "ed25519": ed25519Key} {
+		t.Run(name, func(t *testing.T) {
+			registry, cli := newFakeRegistry(t)
+			image := pushTestImage(registry, "library/app", "v1")
+			signatureDigest := pushTestSignature(t, registry, "library/app", image, signer)
+			ctx := context.Background()
+
+			verifier, err := NewCosignVerifier(cli, publicKeyPEM(t, signer))
+			if err != nil {
+				t.Fatal(err)
+			}
+			signatures, err := verifier.Verify(ctx, "library/app", image.String())
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if len(signatures) != 1 || signatures[0].Payload.Critical.Image.DockerManifestDigest != image.String() {
+				t.Errorf("Verify() = %+v", signatures)
+			}
+
+			accessories := []Accessory{
+				{Digest: "sha256:unrelated", Type: AccessoryTypeSBOM},
+				{Digest: signatureDigest.String(), Type: AccessoryTypeCosignSignature},
+			}
+			if _, err := verifier.VerifyAccessories(ctx, "library/app", image.String(), accessories); err != nil {
+				t.Errorf("VerifyAccessories() error = %v", err)
+			}
+
+			other, _ := NewCosignVerifier(cli, publicKeyPEM(t, otherKey))
+			if _, err := other.Verify(ctx, "library/app", image.String()); !errors.Is(err, ErrCosignVerification) {
+				t.Errorf("Verify() with other key error = %v, want ErrCosignVerification", err)
+			}
+		})
+	}
+}
+
+func TestCosignVerifier_Verify_DigestMismatch(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	registry, cli := newFakeRegistry(t)
+	image := pushTestImage(registry, "library/app", "v1")
+	other := pushTestImage(registry, "library/app", "v2")
+	// a valid signature of v1 copied to the signature tag of v2
+	pushTestSignature(t, registry, "library/app", image, key)
+	signature := registry.manifests["library/app"]["sha256-"+image.Encoded()+".sig"]
+	registry.manifests["library/app"]["sha256-"+other.Encoded()+".sig"] = signature
+
+	verifier, _ := NewCosignVerifier(cli, publicKeyPEM(t, key))
+	if _, err := verifier.Verify(context.Background(), "library/app", other.String()); !errors.Is(err, ErrCosignVerification) {
+		t.Errorf("Verify() error = %v, want ErrCosignVerification", err)
+	}
+	// not signed
+	unsigned := pushTestImage(registry, "library/app", "v3")
+	if _, err := verifier.Verify(context.Background(), "library/app", unsigned.String()); !errors.Is(err, ErrCosignVerification) {
+		t.Errorf("Verify() unsigned error = %v, want ErrCosignVerification", err)
+	}
+	if _, err := verifier.Verify(context.Background(), "library/app", "sha256:"+image.Encoded()[:10]); err == nil {
+		t.Error("Verify() with invalid digest should fail")
+	}
+}
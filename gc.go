@@ -0,0 +1,188 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// GCParameters the parameters of garbage collection job
+type GCParameters struct {
+	DeleteUntagged bool // delete untagged artifacts
+	DryRun         bool // only estimate the free space,no actually deletion
+	Workers        int  // the count of workers,harbor default 1
+}
+
+func (p GCParameters) toMap() map[string]interface{} {
+	params := map[string]interface{}{
+		"delete_untagged": p.DeleteUntagged,
+		"dry_run":         p.DryRun,
+	}
+	if p.Workers > 0 {
+		params["workers"] = p.Workers
+	}
+	return params
+}
+
+// GET /system/gc/schedule
+func (c *Client) GetGCSchedule(ctx context.Context) (ExecHistory, error) {
+	ret := ExecHistory{}
+	if err := c.doRequest(ctx, http.MethodGet, "/system/gc/schedule", nil, &ret); err != nil {
+		return ret, err
+	}
+	return ret, nil
+}
+
+// PUT /system/gc/schedule
+// Use ScheduleTypeNone to remove the schedule.
+func (c *Client) UpdateGCSchedule(ctx context.Context, schedule ScheduleObj, params GCParameters) error {
+	body := Schedule{Schedule: &schedule, Parameters: params.toMap()}
+	return c.doRequest(ctx, http.MethodPut, "/system/gc/schedule", body, nil)
+}
+
+// CreateGCSchedule
+// POST /system/gc/schedule
+func (c *Client) CreateGCSchedule(ctx context.Context, schedule ScheduleObj, params GCParameters) error {
+	body := Schedule{Schedule: &schedule, Parameters: params.toMap()}
+	return c.doRequest(ctx, http.MethodPost, "/system/gc/schedule", body, nil)
+}
+
+// TriggerGC run garbage collection immediately and returns the id of gc execution.
+// POST /system/gc/schedule
+func (c *Client) TriggerGC(ctx context.Context, params GCParameters) (int64, error) {
+	body := Schedule{Schedule: &ScheduleObj{Type: ScheduleTypeManual}, Parameters: params.toMap()}
+	resp, err := c.doRequestWithResponse(ctx, http.MethodPost, "/system/gc/schedule", body, nil)
+	if err != nil {
+		return 0, err
+	}
+	// Location: /api/v2.0/system/gc/12
+	return idFromLocation(resp.Header.Get("Location"))
+}
+
+// GET /system/gc
+func (c *Client) ListGCHistory(ctx context.Context, options CommonListOptions) ([]ExecHistory, error) {
+	path := fmt.Sprintf("/system/gc?%s", options.toQuery().Encode())
+	ret := []ExecHistory{}
+	if err := c.doRequest(ctx, http.MethodGet, path, nil, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// GET /system/gc/{gc_id}
+func (c *Client) GetGC(ctx context.Context, id int64) (ExecHistory, error) {
+	path := fmt.Sprintf("/system/gc/%d", id)
+	ret := ExecHistory{}
+	if err := c.doRequest(ctx, http.MethodGet, path, nil, &ret); err != nil {
+		return ret, err
+	}
+	return ret, nil
+}
+
+// GET /system/gc/{gc_id}/log
+func (c *Client) GetGCLog(ctx context.Context, id int64) ([]byte, error) {
+	path := fmt.Sprintf("/system/gc/%d/log", id)
+	log := []byte{}
+	err := c.doRequest(ctx, http.MethodGet, path, nil, &log)
+	return log, err
+}
+
+// PUT /system/gc/{gc_id}
+func (c *Client) StopGC(ctx context.Context, id int64) error {
+	path := fmt.Sprintf("/system/gc/%d", id)
+	return c.doRequest(ctx, http.MethodPut, path, nil, nil)
+}
+
+// GCResult is the summary of a finished garbage collection
+type GCResult struct {
+	ExecHistory
+	DryRun          bool
+	FreedSpace      int64 // in bytes,the estimated size if DryRun
+	PurgedBlobs     int
+	PurgedManifests int
+}
+
+// WaitForGC polls the gc execution every interval until it finished,
+// then returns the freed-space summary parsed from the job.
+func (c *Client) WaitForGC(ctx context.Context, id int64, interval time.Duration) (*GCResult, error) {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	history := ExecHistory{}
+	err := poll(ctx, interval, interval, func(ctx context.Context) (bool, error) {
+		gc, err := c.GetGC(ctx, id)
+		if err != nil {
+			return false, err
+		}
+		history = gc
+		return IsJobFinished(gc.JobStatus), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := &GCResult{ExecHistory: history}
+	if parseGCJobParameters(history.JobParameters, result) {
+		return result, nil
+	}
+	log, err := c.GetGCLog(ctx, id)
+	if err != nil {
+		return result, err
+	}
+	parseGCLog(log, result)
+	return result, nil
+}
+
+// parseGCJobParameters parse job_parameters of newer harbor which records the result of gc,
+// e.g. {"delete_untagged":true,"dry_run":false,"freed_space":1048576,"purged_blobs":1,"purged_manifests":1}
+func parseGCJobParameters(parameters string, result *GCResult) bool {
+	params := struct {
+		DryRun          bool   `json:"dry_run"`
+		FreedSpace      *int64 `json:"freed_space"`
+		PurgedBlobs     int    `json:"purged_blobs"`
+		PurgedManifests int    `json:"purged_manifests"`
+	}{}
+	if err := json.Unmarshal([]byte(parameters), &params); err != nil {
+		return false
+	}
+	result.DryRun = params.DryRun
+	if params.FreedSpace == nil {
+		return false
+	}
+	result.FreedSpace = *params.FreedSpace
+	result.PurgedBlobs = params.PurgedBlobs
+	result.PurgedManifests = params.PurgedManifests
+	return true
+}
+
+// https://github.com/goharbor/harbor/blob/8d05007eb567/src/jobservice/job/impl/gc/garbage_collection.go#L236
+var (
+	gcDeletedRegexp    = regexp.MustCompile(`(\d+) blobs and (\d+) manifests are actually deleted`)
+	gcFreedRegexp      = regexp.MustCompile(`The GC job actual frees up (\d+) MB space`)
+	gcEligibleRegexp   = regexp.MustCompile(`(\d+) blobs and (\d+) manifests eligible for deletion`)
+	gcEstimatedRegexp  = regexp.MustCompile(`The GC could free up (\d+) MB space`)
+	gcParametersRegexp = regexp.MustCompile(`dry_run: (true|false)`)
+)
+
+const bytesOfMegabyte = 1024 * 1024
+
+func parseGCLog(log []byte, result *GCResult) {
+	if match := gcParametersRegexp.FindSubmatch(log); match != nil {
+		result.DryRun = string(match[1]) == "true"
+	}
+	countsRegexp, sizeRegexp := gcDeletedRegexp, gcFreedRegexp
+	if result.DryRun {
+		countsRegexp, sizeRegexp = gcEligibleRegexp, gcEstimatedRegexp
+	}
+	if match := countsRegexp.FindSubmatch(log); match != nil {
+		result.PurgedBlobs, _ = strconv.Atoi(string(match[1]))
+		result.PurgedManifests, _ = strconv.Atoi(string(match[2]))
+	}
+	if match := sizeRegexp.FindSubmatch(log); match != nil {
+		mb, _ := strconv.ParseInt(string(match[1]), 10, 64)
+		result.FreedSpace = mb * bytesOfMegabyte
+	}
+}
@@ -0,0 +1,38 @@
+package client
+
+import "testing"
+
+func TestParseGCLog(t *testing.T) {
+	log := []byte(`2022-02-17T04:43:09Z [INFO] [/jobservice/job/impl/gc/garbage_collection.go:145]: Garbage Collection parameters: [delete_untagged: true, dry_run: false, time_window: 2]
+2022-02-17T04:43:09Z [INFO] [/jobservice/job/impl/gc/garbage_collection.go:236]: 12 blobs and 3 manifests eligible for deletion
+2022-02-17T04:43:09Z [INFO] [/jobservice/job/impl/gc/garbage_collection.go:237]: The GC could free up 120 MB space, the size is a rough estimation.
+2022-02-17T04:43:11Z [INFO] [/jobservice/job/impl/gc/garbage_collection.go:354]: 10 blobs and 3 manifests are actually deleted
+2022-02-17T04:43:11Z [INFO] [/jobservice/job/impl/gc/garbage_collection.go:355]: The GC job actual frees up 100 MB space.`)
+
+	result := &GCResult{}
+	parseGCLog(log, result)
+	if result.DryRun || result.PurgedBlobs != 10 || result.PurgedManifests != 3 || result.FreedSpace != 100*bytesOfMegabyte {
+		t.Errorf("parseGCLog() = %+v", result)
+	}
+
+	dryrun := &GCResult{}
+	parseGCLog([]byte(`[delete_untagged: false, dry_run: true, time_window: 2]
+12 blobs and 3 manifests eligible for deletion
+The GC could free up 120 MB space, the size is a rough estimation.`), dryrun)
+	if !dryrun.DryRun || dryrun.PurgedBlobs != 12 || dryrun.FreedSpace != 120*bytesOfMegabyte {
+		t.Errorf("parseGCLog() dry run = %+v", dryrun)
+	}
+}
+
+func TestParseGCJobParameters(t *testing.T) {
+	result := &GCResult{}
+	if !parseGCJobParameters(`{"delete_untagged":true,"dry_run":false,"freed_space":1048576,"purged_blobs":2,"purged_manifests":1}`, result) {
+		t.Fatal("parseGCJobParameters() = false")
+	}
+	if result.FreedSpace != 1048576 || result.PurgedBlobs != 2 || result.PurgedManifests != 1 {
+		t.Errorf("parseGCJobParameters() = %+v", result)
+	}
+	if parseGCJobParameters(`{"delete_untagged":true,"dry_run":false}`, &GCResult{}) {
+		t.Error("parseGCJobParameters() without result = true")
+	}
+}
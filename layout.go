@@ -0,0 +1,579 @@
+package client
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	imagespecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// the files of OCI image layout,see: https://github.com/opencontainers/image-spec/blob/v1.0.2/image-layout.md
+const (
+	layoutIndexFile        = "index.json"
+	layoutBlobsDir         = "blobs"
+	dockerSaveManifestFile = "manifest.json"
+)
+
+// AnnotationContainerdName is the annotation of full image name in index.json used by containerd
+const AnnotationContainerdName = "io.containerd.image.name"
+
+// LayoutOptions are the options of exporting OCI image layout
+type LayoutOptions struct {
+	// DockerSave writes the manifest.json of 'docker save' too,
+	// the layout can be loaded by 'docker load' then.
+	DockerSave bool
+	// Platform is the platform of the image in manifest.json if the reference is an index,
+	// the platform of current process if empty.
+	Platform imagespecv1.Platform
+}
+
+// dockerSaveManifest is an entry of the manifest.json of 'docker save'
+type dockerSaveManifest struct {
+	Config   string   `json:"Config"`
+	RepoTags []string `json:"RepoTags"`
+	Layers   []string `json:"Layers"`
+}
+
+// layoutWriter writes the files of OCI image layout
+type layoutWriter interface {
+	// hasBlob reports whether the blob is written already
+	hasBlob(dgst digest.Digest) bool
+	// readFile reads a file written in the layout,an error of os.ErrNotExist if not exists
+	readFile(name string) ([]byte, error)
+	writeBlob(descriptor imagespecv1.Descriptor, content io.Reader) error
+	writeFile(name string, content []byte) error
+}
+
+// ExportOCILayout exports the image of src into the OCI image layout directory dir,
+// all platforms of an index are exported.
+// The image is added into index.json of an existing layout,blobs exist in the layout are not downloaded again.
+// It returns the descriptor of the exported manifest.
+func ExportOCILayout(ctx context.Context, src ImageRef, dir string, options LayoutOptions) (imagespecv1.Descriptor, error) {
+	if err := os.MkdirAll(filepath.Join(dir, layoutBlobsDir), 0o755); err != nil {
+		return imagespecv1.Descriptor{}, err
+	}
+	return exportLayout(ctx, src, &dirLayoutWriter{dir: dir}, options)
+}
+
+// ExportOCILayoutTar exports the image of src as a tarball of OCI image layout into w,
+// all platforms of an index are exported.
+func ExportOCILayoutTar(ctx context.Context, src ImageRef, w io.Writer, options LayoutOptions) (imagespecv1.Descriptor, error) {
+	tw := tar.NewWriter(w)
+	descriptor, err := exportLayout(ctx, src, &tarLayoutWriter{tw: tw, written: map[digest.Digest]bool{}}, options)
+	if err != nil {
+		return descriptor, err
+	}
+	return descriptor, tw.Close()
+}
+
+func exportLayout(ctx context.Context, src ImageRef, w layoutWriter, options LayoutOptions) (imagespecv1.Descriptor, error) {
+	index := newLayoutIndex()
+	if content, err := w.readFile(layoutIndexFile); err == nil {
+		if err := json.Unmarshal(content, index); err != nil {
+			return imagespecv1.Descriptor{}, fmt.Errorf("invalid %s: %w", layoutIndexFile, err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return imagespecv1.Descriptor{}, err
+	}
+	manifest, err := src.Client.GetManifestRaw(ctx, src.Name, src.Reference)
+	if err != nil {
+		return imagespecv1.Descriptor{}, err
+	}
+	if err := exportManifest(ctx, src, w, manifest); err != nil {
+		return imagespecv1.Descriptor{}, err
+	}
+
+	descriptor := manifest.Descriptor
+	refname := ""
+	if _, err := digest.Parse(src.Reference); err != nil {
+		refname = src.Reference
+	}
+	descriptor.Annotations = map[string]string{AnnotationContainerdName: imageName(src)}
+	if refname != "" {
+		descriptor.Annotations[imagespecv1.AnnotationRefName] = refname
+	}
+	// replace the image of same name
+	manifests := []imagespecv1.Descriptor{}
+	for _, exists := range index.Manifests {
+		if exists.Annotations[AnnotationContainerdName] != descriptor.Annotations[AnnotationContainerdName] {
+			manifests = append(manifests, exists)
+		}
+	}
+	index.Manifests = append(manifests, descriptor)
+	content, err := json.Marshal(index)
+	if err != nil {
+		return descriptor, err
+	}
+	if err := w.writeFile(imagespecv1.ImageLayoutFile, []byte(`{"imageLayoutVersion":"`+imagespecv1.ImageLayoutVersion+`"}`)); err != nil {
+		return descriptor, err
+	}
+	if err := w.writeFile(layoutIndexFile, content); err != nil {
+		return descriptor, err
+	}
+	if options.DockerSave {
+		return descriptor, exportDockerSaveManifest(ctx, src, w, manifest, refname, options.Platform)
+	}
+	return descriptor, nil
+}
+
+// exportManifest writes the manifest with its children and blobs
+func exportManifest(ctx context.Context, src ImageRef, w layoutWriter, manifest *RawManifest) error {
+	decoded, err := manifest.Decode()
+	if err != nil {
+		return err
+	}
+	blobs := []imagespecv1.Descriptor{}
+	switch typed := decoded.(type) {
+	case *imagespecv1.Index:
+		for _, child := range typed.Manifests {
+			if w.hasBlob(child.Digest) {
+				continue
+			}
+			childManifest, err := src.Client.GetManifestRaw(ctx, src.Name, child.Digest.String())
+			if err != nil {
+				return err
+			}
+			if err := exportManifest(ctx, src, w, childManifest); err != nil {
+				return err
+			}
+		}
+	case *imagespecv1.Manifest:
+		blobs = append([]imagespecv1.Descriptor{typed.Config}, typed.Layers...)
+	case *ArtifactManifest:
+		blobs = typed.Blobs
+	}
+	for _, blob := range blobs {
+		// foreign layers are not stored in the registry
+		if len(blob.URLs) > 0 || w.hasBlob(blob.Digest) {
+			continue
+		}
+		if err := exportBlob(ctx, src, w, blob); err != nil {
+			return fmt.Errorf("export blob %s: %w", blob.Digest, err)
+		}
+	}
+	// the manifest is written at last,a layout interrupted does not contain a manifest missing blobs
+	return w.writeBlob(manifest.Descriptor, bytes.NewReader(manifest.Content))
+}
+
+func exportBlob(ctx context.Context, src ImageRef, w layoutWriter, blob imagespecv1.Descriptor) error {
+	reader, err := src.Client.GetBlob(ctx, src.Name, blob.Digest)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	// the size is unknown if the registry responses without Content-Length,the content is verified at EOF
+	if reader.Size >= 0 && reader.Size != blob.Size {
+		return &SizeMismatchError{Expected: blob.Size, Actual: reader.Size}
+	}
+	return w.writeBlob(blob, reader)
+}
+
+// exportDockerSaveManifest adds the image of the platform into manifest.json,
+// the entry of the same tag is replaced.
+func exportDockerSaveManifest(ctx context.Context, src ImageRef, w layoutWriter, manifest *RawManifest, tag string, platform imagespecv1.Platform) error {
+	if manifest.IsIndex() {
+		if platform.Architecture == "" {
+			platform = imagespecv1.Platform{OS: runtime.GOOS, Architecture: runtime.GOARCH}
+		}
+		resolved, err := src.Client.ResolvePlatform(ctx, src.Name, manifest.Digest.String(), platform)
+		if err != nil {
+			return err
+		}
+		manifest = resolved.Manifest
+	}
+	image := &imagespecv1.Manifest{}
+	if err := json.Unmarshal(manifest.Content, image); err != nil {
+		return err
+	}
+	entry := dockerSaveManifest{Config: layoutBlobPath(image.Config.Digest), RepoTags: []string{}, Layers: []string{}}
+	if tag != "" {
+		entry.RepoTags = append(entry.RepoTags, imageName(src))
+	}
+	for _, layer := range image.Layers {
+		entry.Layers = append(entry.Layers, layoutBlobPath(layer.Digest))
+	}
+	entries := []dockerSaveManifest{}
+	if content, err := w.readFile(dockerSaveManifestFile); err == nil {
+		if err := json.Unmarshal(content, &entries); err != nil {
+			return fmt.Errorf("invalid %s: %w", dockerSaveManifestFile, err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	content, err := json.Marshal(mergeDockerSaveManifests(entries, entry))
+	if err != nil {
+		return err
+	}
+	return w.writeFile(dockerSaveManifestFile, content)
+}
+
+// mergeDockerSaveManifests adds entry into entries,its tags are removed from other entries,
+// an entry left without tags is dropped,so is an untagged entry of the same config.
+func mergeDockerSaveManifests(entries []dockerSaveManifest, entry dockerSaveManifest) []dockerSaveManifest {
+	tags := map[string]bool{}
+	for _, tag := range entry.RepoTags {
+		tags[tag] = true
+	}
+	merged := []dockerSaveManifest{}
+	for _, exists := range entries {
+		remains := []string{}
+		for _, tag := range exists.RepoTags {
+			if !tags[tag] {
+				remains = append(remains, tag)
+			}
+		}
+		if len(remains) == 0 && (len(exists.RepoTags) > 0 || exists.Config == entry.Config) {
+			continue
+		}
+		exists.RepoTags = remains
+		merged = append(merged, exists)
+	}
+	return append(merged, entry)
+}
+
+// ImportOCILayout pushes the image in OCI image layout directory dir to dst,
+// refname is the 'org.opencontainers.image.ref.name' annotation of the image in index.json,
+// it can be empty if the layout contains only one image.
+// The reference of dst is the refname or the digest of image if empty.
+// The content of blobs and manifests are verified,blobs exist in dst are skipped.
+func ImportOCILayout(ctx context.Context, dir, refname string, dst ImageRef) (imagespecv1.Descriptor, error) {
+	layout := struct {
+		ImageLayoutVersion string `json:"imageLayoutVersion"`
+	}{}
+	content, err := os.ReadFile(filepath.Join(dir, imagespecv1.ImageLayoutFile))
+	if err != nil {
+		return imagespecv1.Descriptor{}, err
+	}
+	if err := json.Unmarshal(content, &layout); err != nil {
+		return imagespecv1.Descriptor{}, err
+	}
+	if layout.ImageLayoutVersion != imagespecv1.ImageLayoutVersion {
+		return imagespecv1.Descriptor{}, fmt.Errorf("unsupported image layout version %q", layout.ImageLayoutVersion)
+	}
+	index, err := readLayoutIndex(dir)
+	if err != nil {
+		return imagespecv1.Descriptor{}, err
+	}
+	descriptor, err := findLayoutManifest(index, refname)
+	if err != nil {
+		return descriptor, err
+	}
+	if dst.Reference == "" {
+		dst.Reference = descriptor.Annotations[imagespecv1.AnnotationRefName]
+	}
+	if dst.Reference == "" {
+		dst.Reference = descriptor.Digest.String()
+	}
+	return descriptor, importManifest(ctx, dir, dst, descriptor, dst.Reference)
+}
+
+// ImportOCILayoutTar pushes the image in tarball of OCI image layout to dst,see ImportOCILayout.
+// The tarball is extracted into a temporary directory.
+func ImportOCILayoutTar(ctx context.Context, r io.Reader, refname string, dst ImageRef) (imagespecv1.Descriptor, error) {
+	dir, err := os.MkdirTemp("", "oci-layout-")
+	if err != nil {
+		return imagespecv1.Descriptor{}, err
+	}
+	defer os.RemoveAll(dir)
+	if err := extractLayoutTar(r, dir); err != nil {
+		return imagespecv1.Descriptor{}, err
+	}
+	return ImportOCILayout(ctx, dir, refname, dst)
+}
+
+func findLayoutManifest(index *imagespecv1.Index, refname string) (imagespecv1.Descriptor, error) {
+	if refname == "" {
+		if len(index.Manifests) != 1 {
+			return imagespecv1.Descriptor{}, fmt.Errorf("layout contains %d images,a ref name is required", len(index.Manifests))
+		}
+		return index.Manifests[0], nil
+	}
+	for _, descriptor := range index.Manifests {
+		if descriptor.Annotations[imagespecv1.AnnotationRefName] == refname {
+			return descriptor, nil
+		}
+	}
+	return imagespecv1.Descriptor{}, fmt.Errorf("image %q not found in layout", refname)
+}
+
+// importManifest pushes the manifest with its children and blobs
+func importManifest(ctx context.Context, dir string, dst ImageRef, descriptor imagespecv1.Descriptor, reference string) error {
+	content, err := readLayoutBlob(dir, descriptor)
+	if err != nil {
+		return err
+	}
+	manifest := &RawManifest{Descriptor: descriptor, Content: content}
+	manifest.MediaType = manifestMediaType(descriptor.MediaType, content)
+	decoded, err := manifest.Decode()
+	if err != nil {
+		return err
+	}
+	blobs := []imagespecv1.Descriptor{}
+	switch typed := decoded.(type) {
+	case *imagespecv1.Index:
+		for _, child := range typed.Manifests {
+			if err := importManifest(ctx, dir, dst, child, child.Digest.String()); err != nil {
+				return err
+			}
+		}
+	case *imagespecv1.Manifest:
+		blobs = append([]imagespecv1.Descriptor{typed.Config}, typed.Layers...)
+	case *ArtifactManifest:
+		blobs = typed.Blobs
+	}
+	for _, blob := range blobs {
+		if len(blob.URLs) > 0 {
+			continue
+		}
+		if err := importBlob(ctx, dir, dst, blob); err != nil {
+			return fmt.Errorf("import blob %s: %w", blob.Digest, err)
+		}
+	}
+	_, err = dst.Client.PutManifest(ctx, dst.Name, reference, manifest.MediaType, content)
+	return err
+}
+
+func importBlob(ctx context.Context, dir string, dst ImageRef, blob imagespecv1.Descriptor) error {
+	if _, err := dst.Client.HeadBlob(ctx, dst.Name, blob.Digest); err == nil {
+		return nil
+	} else if !IsOCINotFound(err) {
+		return err
+	}
+	if err := blob.Digest.Validate(); err != nil {
+		return err
+	}
+	f, err := os.Open(filepath.Join(dir, filepath.FromSlash(layoutBlobPath(blob.Digest))))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() != blob.Size {
+		return &SizeMismatchError{Expected: blob.Size, Actual: info.Size()}
+	}
+	// the digest is verified by PushBlob
+	return dst.Client.PushBlob(ctx, dst.Name, blob.Digest, blob.Size, f)
+}
+
+// readLayoutBlob reads the whole blob in layout and verifies it
+func readLayoutBlob(dir string, descriptor imagespecv1.Descriptor) ([]byte, error) {
+	if err := descriptor.Digest.Validate(); err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(layoutBlobPath(descriptor.Digest))))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) != descriptor.Size {
+		return nil, &SizeMismatchError{Expected: descriptor.Size, Actual: int64(len(content))}
+	}
+	if actual := descriptor.Digest.Algorithm().FromBytes(content); actual != descriptor.Digest {
+		return nil, &DigestMismatchError{Expected: descriptor.Digest, Actual: actual}
+	}
+	return content, nil
+}
+
+func readLayoutIndex(dir string) (*imagespecv1.Index, error) {
+	content, err := os.ReadFile(filepath.Join(dir, layoutIndexFile))
+	if err != nil {
+		return nil, err
+	}
+	index := &imagespecv1.Index{}
+	if err := json.Unmarshal(content, index); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// extractLayoutTar extracts the files of OCI image layout in tarball into dir,other files are ignored.
+func extractLayoutTar(r io.Reader, dir string) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if !isLayoutFile(name) {
+			continue
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		if err := writeFileFrom(target, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// isLayoutFile reports whether name is oci-layout,index.json or a blob of layout
+func isLayoutFile(name string) bool {
+	switch name {
+	case imagespecv1.ImageLayoutFile, layoutIndexFile:
+		return true
+	}
+	parts := strings.Split(name, "/")
+	if len(parts) != 3 || parts[0] != layoutBlobsDir {
+		return false
+	}
+	return digest.NewDigestFromEncoded(digest.Algorithm(parts[1]), parts[2]).Validate() == nil
+}
+
+func newLayoutIndex() *imagespecv1.Index {
+	return &imagespecv1.Index{Versioned: specs.Versioned{SchemaVersion: 2}, Manifests: []imagespecv1.Descriptor{}}
+}
+
+// layoutBlobPath returns the path of blob in layout,e.g. "blobs/sha256/<hex>"
+func layoutBlobPath(dgst digest.Digest) string {
+	return path.Join(layoutBlobsDir, dgst.Algorithm().String(), dgst.Encoded())
+}
+
+// imageName returns the full name of image,e.g. "harbor.example.com/library/nginx:alpine"
+func imageName(ref ImageRef) string {
+	host := ref.Client.Server
+	if u, err := url.Parse(ref.Client.Server); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	separator := ":"
+	if _, err := digest.Parse(ref.Reference); err == nil {
+		separator = "@"
+	}
+	return host + "/" + ref.Name + separator + ref.Reference
+}
+
+// dirLayoutWriter writes the layout into a directory
+type dirLayoutWriter struct {
+	dir string
+}
+
+func (w *dirLayoutWriter) hasBlob(dgst digest.Digest) bool {
+	if dgst.Validate() != nil {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(w.dir, filepath.FromSlash(layoutBlobPath(dgst))))
+	return err == nil
+}
+
+// writeBlob writes the blob into a temporary file and renames it after the content verified
+func (w *dirLayoutWriter) writeBlob(descriptor imagespecv1.Descriptor, content io.Reader) error {
+	if err := descriptor.Digest.Validate(); err != nil {
+		return err
+	}
+	target := filepath.Join(w.dir, filepath.FromSlash(layoutBlobPath(descriptor.Digest)))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	digester := descriptor.Digest.Algorithm().Digester()
+	if err := writeFileFrom(target+".tmp", io.TeeReader(content, digester.Hash())); err != nil {
+		os.Remove(target + ".tmp")
+		return err
+	}
+	if actual := digester.Digest(); actual != descriptor.Digest {
+		os.Remove(target + ".tmp")
+		return &DigestMismatchError{Expected: descriptor.Digest, Actual: actual}
+	}
+	return os.Rename(target+".tmp", target)
+}
+
+func (w *dirLayoutWriter) readFile(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(w.dir, name))
+}
+
+func (w *dirLayoutWriter) writeFile(name string, content []byte) error {
+	// write then rename,avoid a broken index.json on crash
+	target := filepath.Join(w.dir, name)
+	if err := os.WriteFile(target+".tmp", content, 0o644); err != nil {
+		os.Remove(target + ".tmp")
+		return err
+	}
+	return os.Rename(target+".tmp", target)
+}
+
+// tarLayoutWriter writes the layout into a tarball
+type tarLayoutWriter struct {
+	tw      *tar.Writer
+	written map[digest.Digest]bool
+}
+
+func (w *tarLayoutWriter) hasBlob(dgst digest.Digest) bool {
+	return w.written[dgst]
+}
+
+func (w *tarLayoutWriter) writeBlob(descriptor imagespecv1.Descriptor, content io.Reader) error {
+	if err := descriptor.Digest.Validate(); err != nil {
+		return err
+	}
+	header := &tar.Header{
+		Name:    layoutBlobPath(descriptor.Digest),
+		Mode:    0o644,
+		Size:    descriptor.Size,
+		ModTime: time.Unix(0, 0),
+	}
+	if err := w.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	// the tar header is written with the size,never write more than it
+	digester := descriptor.Digest.Algorithm().Digester()
+	n, err := io.Copy(w.tw, io.TeeReader(io.LimitReader(content, descriptor.Size), digester.Hash()))
+	if err != nil {
+		return err
+	}
+	if n != descriptor.Size {
+		return &SizeMismatchError{Expected: descriptor.Size, Actual: n}
+	}
+	if actual := digester.Digest(); actual != descriptor.Digest {
+		return &DigestMismatchError{Expected: descriptor.Digest, Actual: actual}
+	}
+	w.written[descriptor.Digest] = true
+	return nil
+}
+
+// readFile always returns os.ErrNotExist,the tarball is written from scratch
+func (w *tarLayoutWriter) readFile(name string) ([]byte, error) {
+	return nil, &os.PathError{Op: "read", Path: name, Err: os.ErrNotExist}
+}
+
+func (w *tarLayoutWriter) writeFile(name string, content []byte) error {
+	header := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), ModTime: time.Unix(0, 0)}
+	if err := w.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := w.tw.Write(content)
+	return err
+}
+
+func writeFileFrom(name string, content io.Reader) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, content); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,191 @@
+package client
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	imagespecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestExportImportOCILayout(t *testing.T) {
+	srcRegistry, srcClient := newFakeRegistry(t)
+	dstRegistry, dstClient := newFakeRegistry(t)
+	ctx := context.Background()
+	index := pushTestIndex(srcRegistry, "library/app", "v1", map[string]imagespecv1.Platform{
+		"amd64": {OS: "linux", Architecture: "amd64"},
+		"arm64": {OS: "linux", Architecture: "arm64"},
+	})
+	src := ImageRef{Client: srcClient, Name: "library/app", Reference: "v1"}
+	// the registry responses blobs without Content-Length
+	srcRegistry.chunkedBlobs = true
+
+	dir := t.TempDir()
+	for i := 0; i < 2; i++ {
+		descriptor, err := ExportOCILayout(ctx, src, dir, LayoutOptions{})
+		if err != nil {
+			t.Fatalf("ExportOCILayout() error = %v", err)
+		}
+		if descriptor.Digest != index {
+			t.Errorf("ExportOCILayout() digest = %s, want %s", descriptor.Digest, index)
+		}
+	}
+	layoutIndex, err := readLayoutIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(layoutIndex.Manifests) != 1 || layoutIndex.Manifests[0].Annotations[imagespecv1.AnnotationRefName] != "v1" {
+		t.Errorf("index.json manifests = %+v", layoutIndex.Manifests)
+	}
+	for dgst := range srcRegistry.blobs["library/app"] {
+		if _, err := os.Stat(filepath.Join(dir, layoutBlobPath(dgst))); err != nil {
+			t.Errorf("blob %s not exported: %v", dgst, err)
+		}
+	}
+
+	descriptor, err := ImportOCILayout(ctx, dir, "", ImageRef{Client: dstClient, Name: "mirror/app"})
+	if err != nil {
+		t.Fatalf("ImportOCILayout() error = %v", err)
+	}
+	if descriptor.Digest != index || dstRegistry.manifests["mirror/app"]["v1"].content == nil {
+		t.Errorf("ImportOCILayout() = %+v,tags %v", descriptor, dstRegistry.manifests["mirror/app"])
+	}
+	for dgst, content := range srcRegistry.blobs["library/app"] {
+		if got := dstRegistry.blobs["mirror/app"][dgst]; !bytes.Equal(got, content) {
+			t.Errorf("blob %s = %q, want %q", dgst, got, content)
+		}
+	}
+
+	// a layer corrupted on disk
+	manifest, _ := srcClient.GetManifest(ctx, "library/app", "amd64")
+	path := filepath.Join(dir, layoutBlobPath(manifest.Layers[0].Digest))
+	content, _ := os.ReadFile(path)
+	_ = os.WriteFile(path, bytes.ToUpper(content), 0o644)
+	_, err = ImportOCILayout(ctx, dir, "v1", ImageRef{Client: dstClient, Name: "other/app"})
+	if mismatch := (&DigestMismatchError{}); !errors.As(err, &mismatch) {
+		t.Errorf("ImportOCILayout() error = %v, want *DigestMismatchError", err)
+	}
+}
+
+func TestExportOCILayout_DockerSave(t *testing.T) {
+	registry, cli := newFakeRegistry(t)
+	ctx := context.Background()
+	pushTestImage(registry, "library/app", "v1")
+	pushTestImage(registry, "library/app", "v2")
+	dir := t.TempDir()
+	for _, tag := range []string{"v1", "v2", "v1"} {
+		if _, err := ExportOCILayout(ctx, ImageRef{Client: cli, Name: "library/app", Reference: tag}, dir, LayoutOptions{DockerSave: true}); err != nil {
+			t.Fatalf("ExportOCILayout(%s) error = %v", tag, err)
+		}
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := []dockerSaveManifest{}
+	if err := json.Unmarshal(content, &saved); err != nil {
+		t.Fatal(err)
+	}
+	tags := []string{}
+	for _, entry := range saved {
+		tags = append(tags, entry.RepoTags...)
+	}
+	v1 := imageName(ImageRef{Client: cli, Name: "library/app", Reference: "v1"})
+	v2 := imageName(ImageRef{Client: cli, Name: "library/app", Reference: "v2"})
+	if len(saved) != 2 || !equalStrings(tags, []string{v2, v1}) {
+		t.Errorf("manifest.json = %+v", saved)
+	}
+}
+
+func TestExportImportOCILayoutTar(t *testing.T) {
+	srcRegistry, srcClient := newFakeRegistry(t)
+	dstRegistry, dstClient := newFakeRegistry(t)
+	ctx := context.Background()
+	pushTestIndex(srcRegistry, "library/app", "v1", map[string]imagespecv1.Platform{
+		"amd64": {OS: "linux", Architecture: "amd64"},
+		"arm64": {OS: "linux", Architecture: "arm64"},
+	})
+	arm64, _ := srcClient.GetManifest(ctx, "library/app", "arm64")
+
+	buf := &bytes.Buffer{}
+	src := ImageRef{Client: srcClient, Name: "library/app", Reference: "v1"}
+	options := LayoutOptions{DockerSave: true, Platform: imagespecv1.Platform{OS: "linux", Architecture: "arm64"}}
+	if _, err := ExportOCILayoutTar(ctx, src, buf, options); err != nil {
+		t.Fatalf("ExportOCILayoutTar() error = %v", err)
+	}
+
+	files := map[string][]byte{}
+	tr := tar.NewReader(bytes.NewReader(buf.Bytes()))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[header.Name], _ = io.ReadAll(tr)
+	}
+	for _, name := range []string{"oci-layout", "index.json", "manifest.json"} {
+		if files[name] == nil {
+			t.Errorf("%s not exported", name)
+		}
+	}
+	saved := []dockerSaveManifest{}
+	if err := json.Unmarshal(files["manifest.json"], &saved); err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 1 || len(saved[0].Layers) != 1 || saved[0].Layers[0] != layoutBlobPath(arm64.Layers[0].Digest) {
+		t.Errorf("manifest.json = %+v", saved)
+	}
+	if len(saved[0].RepoTags) != 1 || saved[0].RepoTags[0] != imageName(src) {
+		t.Errorf("manifest.json RepoTags = %v", saved[0].RepoTags)
+	}
+	if files[saved[0].Config] == nil {
+		t.Errorf("config %s not exported", saved[0].Config)
+	}
+
+	if _, err := ImportOCILayoutTar(ctx, bytes.NewReader(buf.Bytes()), "v1", ImageRef{Client: dstClient, Name: "mirror/app", Reference: "latest"}); err != nil {
+		t.Fatalf("ImportOCILayoutTar() error = %v", err)
+	}
+	if dstRegistry.manifests["mirror/app"]["latest"].content == nil {
+		t.Error("tag latest not imported")
+	}
+	arm64Digest := digest.FromBytes(srcRegistry.manifests["library/app"]["arm64"].content)
+	if _, ok := dstRegistry.manifests["mirror/app"][arm64Digest.String()]; !ok {
+		t.Error("arm64 manifest not imported")
+	}
+}
+
+func TestTarLayoutWriter_WriteBlob(t *testing.T) {
+	content := []byte("layer content")
+	descriptor := imagespecv1.Descriptor{Digest: digest.FromBytes(content), Size: int64(len(content))}
+	tests := []struct {
+		name    string
+		content []byte
+		wantErr interface{}
+	}{
+		{name: "short", content: content[:5], wantErr: new(*SizeMismatchError)},
+		{name: "longer", content: append([]byte("prefix "), content...), wantErr: new(*DigestMismatchError)},
+		{name: "tampered", content: bytes.ToUpper(content), wantErr: new(*DigestMismatchError)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &tarLayoutWriter{tw: tar.NewWriter(io.Discard), written: map[digest.Digest]bool{}}
+			err := w.writeBlob(descriptor, bytes.NewReader(tt.content))
+			if err == nil || !errors.As(err, tt.wantErr) {
+				t.Errorf("writeBlob() error = %v, want %T", err, tt.wantErr)
+			}
+			if w.hasBlob(descriptor.Digest) {
+				t.Error("blob marked written")
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	imagespecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// MediaTypeArtifactManifest is the media type of OCI artifact manifest,
+// it is withdrawn from the final image-spec v1.1 and not accepted by default,
+// pass it to GetManifestRaw explicitly for registries still serving it.
+const MediaTypeArtifactManifest = "application/vnd.oci.artifact.manifest.v1+json"
+
+// DefaultManifestMediaTypes are the manifest media types accepted by GetManifestRaw by default
+var DefaultManifestMediaTypes = []string{
+	imagespecv1.MediaTypeImageManifest,
+	imagespecv1.MediaTypeImageIndex,
+	MediaTypeDockerManifest,
+	MediaTypeDockerManifestList,
+}
+
+// ArtifactManifest is the OCI artifact manifest,
+// see: https://github.com/opencontainers/image-spec/blob/v1.1.0-rc2/artifact.md
+type ArtifactManifest struct {
+	MediaType    string                   `json:"mediaType"`
+	ArtifactType string                   `json:"artifactType"`
+	Blobs        []imagespecv1.Descriptor `json:"blobs,omitempty"`
+	Subject      *imagespecv1.Descriptor  `json:"subject,omitempty"`
+	Annotations  map[string]string        `json:"annotations,omitempty"`
+}
+
+// RawManifest is a manifest as it stored in the registry
+type RawManifest struct {
+	imagespecv1.Descriptor // media type,digest and size of the manifest
+	Content                []byte
+}
+
+// IsIndex reports whether the manifest is an OCI image index or a docker manifest list
+func (m *RawManifest) IsIndex() bool {
+	return m.MediaType == imagespecv1.MediaTypeImageIndex || m.MediaType == MediaTypeDockerManifestList
+}
+
+// Decode decodes the manifest by its media type,the result can be:
+// *imagespecv1.Manifest for OCI image manifest and docker manifest v2 schema 2,
+// *imagespecv1.Index for OCI image index and docker manifest list,
+// *ArtifactManifest for OCI artifact manifest.
+func (m *RawManifest) Decode() (interface{}, error) {
+	var into interface{}
+	switch m.MediaType {
+	case imagespecv1.MediaTypeImageManifest, MediaTypeDockerManifest:
+		into = &imagespecv1.Manifest{}
+	case imagespecv1.MediaTypeImageIndex, MediaTypeDockerManifestList:
+		into = &imagespecv1.Index{}
+	case MediaTypeArtifactManifest:
+		into = &ArtifactManifest{}
+	default:
+		return nil, fmt.Errorf("unsupported manifest media type %q", m.MediaType)
+	}
+	if err := json.Unmarshal(m.Content, into); err != nil {
+		return nil, err
+	}
+	return into, nil
+}
+
+// GetManifestRaw gets the manifest as it stored,
+// accept are the acceptable media types,DefaultManifestMediaTypes if empty.
+// The content is verified if reference is a digest.
+// end-3	GET	/v2/<name>/manifests/<reference>	200	404
+func (c *OCIDistributionClient) GetManifestRaw(ctx context.Context, name, reference string, accept ...string) (*RawManifest, error) {
+	resp, err := c.manifestRequest(ctx, http.MethodGet, name, reference, accept)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &RawManifest{Content: content}
+	manifest.MediaType = manifestMediaType(resp.Header.Get("Content-Type"), content)
+	manifest.Size = int64(len(content))
+	expected, err := manifestDigest(reference, resp.Header.Get("Docker-Content-Digest"))
+	if err != nil {
+		return nil, err
+	}
+	if expected == "" {
+		manifest.Digest = digest.FromBytes(content)
+		return manifest, nil
+	}
+	if actual := expected.Algorithm().FromBytes(content); actual != expected {
+		return nil, &DigestMismatchError{Expected: expected, Actual: actual}
+	}
+	manifest.Digest = expected
+	return manifest, nil
+}
+
+// PutManifest pushes the manifest content of mediaType to reference,reference is a tag or the digest of content.
+// It returns the descriptor of the pushed manifest.
+// end-7	PUT	/v2/<name>/manifests/<reference>	201	404
+func (c *OCIDistributionClient) PutManifest(ctx context.Context, name, reference, mediaType string, content []byte) (imagespecv1.Descriptor, error) {
+	descriptor, _, err := c.putManifest(ctx, name, reference, mediaType, content)
+	return descriptor, err
+}
+
+// putManifest pushes the manifest and returns the response header too
+func (c *OCIDistributionClient) putManifest(ctx context.Context, name, reference, mediaType string, content []byte) (imagespecv1.Descriptor, http.Header, error) {
+	descriptor := imagespecv1.Descriptor{MediaType: mediaType, Digest: digest.FromBytes(content), Size: int64(len(content))}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.Server+"/v2/"+name+"/manifests/"+reference, bytes.NewReader(content))
+	if err != nil {
+		return descriptor, nil, err
+	}
+	req.Header.Set("Content-Type", mediaType)
+	resp, err := c.do(req)
+	if err != nil {
+		return descriptor, nil, err
+	}
+	resp.Body.Close()
+	if dgst := resp.Header.Get("Docker-Content-Digest"); dgst != "" && dgst != descriptor.Digest.String() {
+		return descriptor, resp.Header, &DigestMismatchError{Expected: descriptor.Digest, Actual: digest.Digest(dgst)}
+	}
+	return descriptor, resp.Header, nil
+}
+
+// manifestRequest sends a GET or HEAD manifest request with Accept of media types
+func (c *OCIDistributionClient) manifestRequest(ctx context.Context, method, name, reference string, accept []string) (*http.Response, error) {
+	if len(accept) == 0 {
+		accept = DefaultManifestMediaTypes
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.Server+"/v2/"+name+"/manifests/"+reference, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(accept, ", "))
+	return c.do(req)
+}
+
+// manifestMediaType returns the media type from Content-Type,
+// or from the mediaType field of content if the registry responses a generic content type.
+func manifestMediaType(contentType string, content []byte) string {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && mediaType != "application/json" && mediaType != "text/plain" {
+		return mediaType
+	}
+	header := struct {
+		MediaType string            `json:"mediaType"`
+		Manifests []json.RawMessage `json:"manifests"`
+	}{}
+	if err := json.Unmarshal(content, &header); err != nil {
+		return contentType
+	}
+	switch {
+	case header.MediaType != "":
+		return header.MediaType
+	case header.Manifests != nil:
+		return imagespecv1.MediaTypeImageIndex
+	default:
+		return imagespecv1.MediaTypeImageManifest
+	}
+}
+
+// manifestDigest returns the expected digest of manifest,
+// the reference if it is a digest,otherwise the Docker-Content-Digest header.
+// It is empty if unknown.
+func manifestDigest(reference, header string) (digest.Digest, error) {
+	if dgst, err := digest.Parse(reference); err == nil {
+		if header != "" && header != reference {
+			return "", &DigestMismatchError{Expected: dgst, Actual: digest.Digest(header)}
+		}
+		return dgst, nil
+	}
+	if header == "" {
+		return "", nil
+	}
+	return digest.Parse(header)
+}
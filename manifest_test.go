@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	imagespecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestOCIDistributionClient_PutManifest(t *testing.T) {
+	registry, cli := newFakeRegistry(t)
+	ctx := context.Background()
+	image := pushTestImage(registry, "library/app", "v1")
+	index, _ := json.Marshal(imagespecv1.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: imagespecv1.MediaTypeImageIndex,
+		Manifests: []imagespecv1.Descriptor{{
+			MediaType: imagespecv1.MediaTypeImageManifest,
+			Digest:    image,
+			Platform:  &imagespecv1.Platform{OS: "linux", Architecture: "amd64"},
+		}},
+	})
+
+	descriptor, err := cli.PutManifest(ctx, "library/app", "latest", imagespecv1.MediaTypeImageIndex, index)
+	if err != nil {
+		t.Fatalf("PutManifest() error = %v", err)
+	}
+	if descriptor.Digest != digest.FromBytes(index) || descriptor.Size != int64(len(index)) {
+		t.Errorf("PutManifest() = %+v", descriptor)
+	}
+
+	for _, reference := range []string{"latest", descriptor.Digest.String()} {
+		manifest, err := cli.GetManifestRaw(ctx, "library/app", reference)
+		if err != nil {
+			t.Fatalf("GetManifestRaw() error = %v", err)
+		}
+		if manifest.MediaType != imagespecv1.MediaTypeImageIndex || manifest.Digest != descriptor.Digest || !manifest.IsIndex() {
+			t.Errorf("GetManifestRaw() = %+v", manifest.Descriptor)
+		}
+		decoded, err := manifest.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if typed, ok := decoded.(*imagespecv1.Index); !ok || typed.Manifests[0].Digest != image {
+			t.Errorf("Decode() = %#v", decoded)
+		}
+	}
+
+	// an index is unknown to the client accepts manifests only
+	if _, err := cli.GetManifest(ctx, "library/app", "latest"); !IsOCINotFound(err) {
+		t.Errorf("GetManifest() error = %v, want not found", err)
+	}
+	if manifest, err := cli.GetManifest(ctx, "library/app", "v1"); err != nil || len(manifest.Layers) != 1 {
+		t.Errorf("GetManifest() = %+v, %v", manifest, err)
+	}
+}
+
+func TestOCIDistributionClient_GetManifestRaw_DigestMismatch(t *testing.T) {
+	registry, cli := newFakeRegistry(t)
+	image := pushTestImage(registry, "library/app", "v1")
+	other := pushTestImage(registry, "library/app", "v2")
+	registry.manifests["library/app"][image.String()] = registry.manifests["library/app"][other.String()]
+
+	_, err := cli.GetManifestRaw(context.Background(), "library/app", image.String())
+	if mismatch := (&DigestMismatchError{}); !errors.As(err, &mismatch) {
+		t.Errorf("GetManifestRaw() error = %v, want *DigestMismatchError", err)
+	}
+}
+
+func TestOCIDistributionClient_GetManifestRaw_ArtifactManifest(t *testing.T) {
+	registry, cli := newFakeRegistry(t)
+	ctx := context.Background()
+	registry.putManifest("library/app", MediaTypeArtifactManifest, ArtifactManifest{
+		MediaType: MediaTypeArtifactManifest, ArtifactType: "application/spdx+json",
+	}, "sbom")
+
+	// the withdrawn artifact manifest is not accepted by default
+	if _, err := cli.GetManifestRaw(ctx, "library/app", "sbom"); !IsOCINotFound(err) {
+		t.Errorf("GetManifestRaw() error = %v, want not found", err)
+	}
+	manifest, err := cli.GetManifestRaw(ctx, "library/app", "sbom", MediaTypeArtifactManifest)
+	if err != nil {
+		t.Fatalf("GetManifestRaw() error = %v", err)
+	}
+	if decoded, err := manifest.Decode(); err != nil {
+		t.Errorf("Decode() error = %v", err)
+	} else if artifact, ok := decoded.(*ArtifactManifest); !ok || artifact.ArtifactType != "application/spdx+json" {
+		t.Errorf("Decode() = %+v", decoded)
+	}
+}
+
+func Test_manifestMediaType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		content     string
+		want        string
+	}{
+		{contentType: MediaTypeDockerManifest, content: `{}`, want: MediaTypeDockerManifest},
+		{contentType: "application/json; charset=utf-8", content: `{"mediaType":"` + MediaTypeDockerManifestList + `"}`, want: MediaTypeDockerManifestList},
+		{contentType: "", content: `{"schemaVersion":2,"manifests":[]}`, want: imagespecv1.MediaTypeImageIndex},
+		{contentType: "text/plain", content: `{"schemaVersion":2,"layers":[]}`, want: imagespecv1.MediaTypeImageManifest},
+	}
+	for _, tt := range tests {
+		if got := manifestMediaType(tt.contentType, []byte(tt.content)); got != tt.want {
+			t.Errorf("manifestMediaType(%q, %s) = %s, want %s", tt.contentType, tt.content, got, tt.want)
+		}
+	}
+}
@@ -4,21 +4,33 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
 
 	distributionspecsv1 "github.com/opencontainers/distribution-spec/specs-go/v1"
+	"github.com/opencontainers/go-digest"
 	imagespecv1 "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
+// media types of docker image manifest v2 schema 2
+const (
+	MediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
 type OCIDistributionClient struct {
 	Server string
 	Auth   Auth
+	// tokens of the registry token authentication,see tokenKey
+	tokens sync.Map
 }
 
 // OCI Distribution Specification Client
 //
+// auth is sent to the registry,or to the token server if the registry challenges with 'WWW-Authenticate: Bearer'.
 // For more information visit below URL
 // https://github.com/opencontainers/distribution-spec/blob/main/spec.md#endpoints
 func NewOCIDistributionClient(server string, auth Auth) (*OCIDistributionClient, error) {
@@ -35,10 +47,12 @@ func (c *OCIDistributionClient) Ping(ctx context.Context) error {
 	return c.request(ctx, http.MethodGet, "/v2", nil, nil)
 }
 
+// GetManifest gets the OCI image manifest or docker manifest v2 schema 2,
+// use GetManifestRaw for other types of manifest.
 // end-3 	GET/HEAD /v2/<name>/manifests/<reference>
 func (c *OCIDistributionClient) GetManifest(ctx context.Context, name, reference string) (*imagespecv1.Manifest, error) {
 	manifest := &imagespecv1.Manifest{}
-	err := c.request(ctx, http.MethodGet, "/v2/"+name+"/manifests/"+reference, nil, manifest)
+	err := c.getManifestJSON(ctx, name, reference, manifest)
 	return manifest, err
 }
 
@@ -49,9 +63,11 @@ func (c *OCIDistributionClient) ListTags(ctx context.Context, name string) (*dis
 	return tags, err
 }
 
-// end-8b	GET	/v2/<name>/tags/list?n=<integer>&last=<integer>
-func (c *OCIDistributionClient) ListTagsPaged(ctx context.Context, name string, n, last int) (*distributionspecsv1.TagList, error) {
-	path := fmt.Sprintf("/v2/%s/tags/list?n=%d&last=%d", name, n, last)
+// ListTagsPaged lists at most n tags after the tag 'last' in lexical order,
+// use TagIterator to walk all tags by the Link header.
+// end-8b	GET	/v2/<name>/tags/list?n=<integer>&last=<last tag value from previous response>
+func (c *OCIDistributionClient) ListTagsPaged(ctx context.Context, name string, n int, last string) (*distributionspecsv1.TagList, error) {
+	path := "/v2/" + name + "/tags/list" + pageQuery(n, last)
 	tags := &distributionspecsv1.TagList{}
 	err := c.request(ctx, http.MethodGet, path, nil, tags)
 	return tags, err
@@ -62,6 +78,25 @@ func (c *OCIDistributionClient) DeleteManifest(ctx context.Context, name, refere
 	return c.request(ctx, http.MethodDelete, "/v2/"+name+"/manifests/"+reference, nil, nil)
 }
 
+// getManifestJSON gets the OCI or docker v2 manifest and decodes it into 'into'
+func (c *OCIDistributionClient) getManifestJSON(ctx context.Context, name, reference string, into interface{}) error {
+	manifest, err := c.GetManifestRaw(ctx, name, reference, imagespecv1.MediaTypeImageManifest, MediaTypeDockerManifest)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(manifest.Content, into)
+}
+
+// getBlobBytes reads the whole blob and verifies its digest
+func (c *OCIDistributionClient) getBlobBytes(ctx context.Context, name string, dgst digest.Digest) ([]byte, error) {
+	blob, err := c.GetBlob(ctx, name, dgst)
+	if err != nil {
+		return nil, err
+	}
+	defer blob.Close()
+	return io.ReadAll(blob)
+}
+
 func (c *OCIDistributionClient) request(ctx context.Context, method string, path string, postbody interface{}, into interface{}) error {
 	var body io.Reader
 	switch typed := postbody.(type) {
@@ -86,22 +121,102 @@ func (c *OCIDistributionClient) request(ctx context.Context, method string, path
 	if err != nil {
 		return err
 	}
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if into == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(into)
+}
+
+// do sends the request with auth,or the bearer token if the registry uses token authentication,
+// the response body must be closed by caller if no error returned.
+// A non 2xx response is returned as *StatusError.
+func (c *OCIDistributionClient) do(req *http.Request) (*http.Response, error) {
 	if c.Auth != nil {
 		c.Auth(req)
 	}
-	resp, err := http.DefaultClient.Do(req)
+	key := tokenKey(req)
+	if token, ok := c.tokens.Load(key); ok {
+		req.Header.Set("Authorization", "Bearer "+token.(string))
+	}
+	resp, err := ociHTTPClient.Do(req)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	// registry token authentication,get a token with Auth for the challenged scope and retry once.
+	// A request with body can not be sent again is not retried,the token is cached for next requests.
+	if challenge, ok := parseBearerChallenge(resp.Header.Get("WWW-Authenticate")); ok && resp.StatusCode == http.StatusUnauthorized {
+		token, err := c.fetchToken(req.Context(), challenge)
+		if err != nil {
+			resp.Body.Close()
+			return nil, fmt.Errorf("%s %s: get token: %w", req.Method, req.URL.Path, err)
+		}
+		c.tokens.Store(key, token)
+		if retry, ok := rewind(req); ok {
+			resp.Body.Close()
+			retry.Header.Set("Authorization", "Bearer "+token)
+			if resp, err = ociHTTPClient.Do(retry); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
 	}
 	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		errresp := &distributionspecsv1.ErrorResponse{}
-		if err := json.NewDecoder(resp.Body).Decode(errresp); err != nil {
-			return err
+	statuserr := &StatusError{Method: req.Method, Path: req.URL.Path, StatusCode: resp.StatusCode}
+	errresp := &distributionspecsv1.ErrorResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(errresp); err == nil && len(errresp.Errors) > 0 {
+		statuserr.Response = errresp
+	}
+	return nil, statuserr
+}
+
+// ociHTTPClient follows redirects to storage backends,
+// the auth header is dropped when redirected to another host.
+var ociHTTPClient = &http.Client{
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		if req.URL.Host != via[0].URL.Host {
+			req.Header.Del("Authorization")
 		}
-		return errresp
+		return nil
+	},
+}
+
+// StatusError is returned when the registry responses a non 2xx status,
+// it unwraps to *distributionspecsv1.ErrorResponse if the response has an error body.
+type StatusError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Response   *distributionspecsv1.ErrorResponse
+}
+
+func (e *StatusError) Error() string {
+	if e.Response != nil {
+		return e.Response.Error()
 	}
-	return json.NewDecoder(resp.Body).Decode(into)
+	return fmt.Sprintf("%s %s: unexpected status %d %s", e.Method, e.Path, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+func (e *StatusError) Unwrap() error {
+	if e.Response == nil {
+		return nil
+	}
+	return e.Response
+}
+
+// IsOCINotFound reports whether err is a 404 response of the registry
+func IsOCINotFound(err error) bool {
+	statuserr := &StatusError{}
+	return errors.As(err, &statuserr) && statuserr.StatusCode == http.StatusNotFound
 }
 
 // https://github.com/opencontainers/distribution-spec/blob/main/spec.md#error-codes
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// bearerChallenge is the 'WWW-Authenticate: Bearer' challenge of the registry token authentication
+// see: https://docs.docker.com/registry/spec/auth/token/
+type bearerChallenge struct {
+	Realm   string
+	Service string
+	Scope   string // space separated scopes,e.g. "repository:library/nginx:pull,push"
+}
+
+var challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// parseBearerChallenge parses header like: Bearer realm="https://harbor/service/token",service="harbor-registry",scope="repository:library/nginx:pull"
+func parseBearerChallenge(header string) (bearerChallenge, bool) {
+	challenge := bearerChallenge{}
+	if len(header) < len("Bearer ") || !strings.EqualFold(header[:len("Bearer ")], "Bearer ") {
+		return challenge, false
+	}
+	for _, match := range challengeParam.FindAllStringSubmatch(header[len("Bearer "):], -1) {
+		switch strings.ToLower(match[1]) {
+		case "realm":
+			challenge.Realm = match[2]
+		case "service":
+			challenge.Service = match[2]
+		case "scope":
+			challenge.Scope = match[2]
+		}
+	}
+	return challenge, challenge.Realm != ""
+}
+
+// fetchToken gets a token for the challenge from the token server with Auth,
+// anonymous token is requested if no Auth.
+func (c *OCIDistributionClient) fetchToken(ctx context.Context, challenge bearerChallenge) (string, error) {
+	query := url.Values{}
+	if challenge.Service != "" {
+		query.Set("service", challenge.Service)
+	}
+	for _, scope := range strings.Fields(challenge.Scope) {
+		query.Add("scope", scope)
+	}
+	realm := challenge.Realm
+	if len(query) > 0 {
+		realm += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm, nil)
+	if err != nil {
+		return "", err
+	}
+	if c.Auth != nil {
+		c.Auth(req)
+	}
+	resp, err := ociHTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", &StatusError{Method: req.Method, Path: req.URL.Path, StatusCode: resp.StatusCode}
+	}
+	token := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("decode token response: %w", err)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	if token.Token == "" {
+		return "", fmt.Errorf("no token returned from %s", challenge.Realm)
+	}
+	return token.Token, nil
+}
+
+var repositoryPath = regexp.MustCompile(`^/v2/(.+?)/(blobs|manifests|tags|referrers)/`)
+
+// tokenKey is the key of cached token of the request,
+// tokens are cached by repository and pull or push as registries grant actions on repository,
+// a pull token does not replace the push token of concurrent uploads then.
+func tokenKey(req *http.Request) string {
+	action := "push"
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		action = "pull"
+	}
+	if match := repositoryPath.FindStringSubmatch(req.URL.Path); match != nil {
+		return req.URL.Host + "/" + match[1] + ":" + action
+	}
+	return req.URL.Host + ":" + action
+}
+
+// rewind returns a copy of the request to send again,false if the body can not be read again.
+func rewind(req *http.Request) (*http.Request, bool) {
+	retry := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return retry, true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	retry.Body = body
+	return retry, true
+}
@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// fakeTokenServer protects the fake registry with the registry token authentication,
+// tokens are issued to the user with basic auth and grant the requested scopes.
+type fakeTokenServer struct {
+	registry           http.Handler
+	username, password string
+	url                string
+	mu                 sync.Mutex
+	issued             []string // scopes of issued tokens
+}
+
+func newTokenFakeRegistry(t *testing.T, username, password string) (*fakeRegistry, *fakeTokenServer) {
+	registry := &fakeRegistry{
+		manifests: map[string]map[string]fakeManifest{},
+		blobs:     map[string]map[digest.Digest][]byte{},
+		uploads:   map[string]*fakeUpload{},
+	}
+	tokens := &fakeTokenServer{registry: registry, username: username, password: password}
+	server := httptest.NewServer(tokens)
+	t.Cleanup(server.Close)
+	tokens.url = server.URL
+	return registry, tokens
+}
+
+func (s *fakeTokenServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/service/token" {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != s.username || password != s.password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		scopes := strings.Join(r.URL.Query()["scope"], " ")
+		s.mu.Lock()
+		s.issued = append(s.issued, scopes)
+		s.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": base64.RawURLEncoding.EncodeToString([]byte(scopes))})
+		return
+	}
+	scope := "registry:catalog:*"
+	if match := repositoryPath.FindStringSubmatch(r.URL.Path); match != nil {
+		scope = "repository:" + match[1] + ":pull"
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			scope += ",push"
+		}
+	}
+	if !s.granted(r.Header.Get("Authorization"), scope) {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/service/token",service="fake-registry",scope="%s"`, s.url, scope))
+		writeRegistryError(w, http.StatusUnauthorized, "UNAUTHORIZED")
+		return
+	}
+	s.registry.ServeHTTP(w, r)
+}
+
+// granted reports whether the bearer token grants the scope,push scope grants pull too
+func (s *fakeTokenServer) granted(authorization, scope string) bool {
+	token := strings.TrimPrefix(authorization, "Bearer ")
+	scopes, err := base64.RawURLEncoding.DecodeString(token)
+	if token == authorization || err != nil {
+		return false
+	}
+	for _, granted := range strings.Fields(string(scopes)) {
+		if granted == scope || granted == scope+",push" {
+			return true
+		}
+	}
+	return false
+}
+
+func TestParseBearerChallenge(t *testing.T) {
+	challenge, ok := parseBearerChallenge(`Bearer realm="https://harbor.example.com/service/token",service="harbor-registry",scope="repository:library/nginx:pull,push"`)
+	want := bearerChallenge{Realm: "https://harbor.example.com/service/token", Service: "harbor-registry", Scope: "repository:library/nginx:pull,push"}
+	if !ok || challenge != want {
+		t.Errorf("parseBearerChallenge() = %+v, %v", challenge, ok)
+	}
+	if _, ok := parseBearerChallenge(`Basic realm="harbor"`); ok {
+		t.Error("parseBearerChallenge() accepts basic challenge")
+	}
+}
+
+func TestOCIDistributionClient_TokenAuth(t *testing.T) {
+	registry, tokens := newTokenFakeRegistry(t, "admin", "Harbor12345")
+	image := pushTestImage(registry, "library/app", "v1")
+	ctx := context.Background()
+
+	cli, _ := NewOCIDistributionClient(tokens.url, BasicAuth("admin", "Harbor12345"))
+	if _, err := cli.GetManifest(ctx, "library/app", "v1"); err != nil {
+		t.Fatalf("GetManifest() error = %v", err)
+	}
+	// the cached token is reused
+	if _, err := cli.GetManifest(ctx, "library/app", image.String()); err != nil {
+		t.Fatalf("GetManifest() error = %v", err)
+	}
+	// the streaming body can not be sent again,the upload session is challenged first
+	content := []byte("pushed layer")
+	if err := cli.PushBlob(ctx, "library/app", digest.FromBytes(content), int64(len(content)), strings.NewReader(string(content))); err != nil {
+		t.Fatalf("PushBlob() error = %v", err)
+	}
+	want := []string{"repository:library/app:pull", "repository:library/app:pull,push"}
+	if !equalStrings(tokens.issued, want) {
+		t.Errorf("issued tokens = %v, want %v", tokens.issued, want)
+	}
+
+	wrong, _ := NewOCIDistributionClient(tokens.url, BasicAuth("admin", "wrong"))
+	statuserr := &StatusError{}
+	if _, err := wrong.GetManifest(ctx, "library/app", "v1"); !errors.As(err, &statuserr) || statuserr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("GetManifest() with wrong password error = %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// ResolveDigest resolves the tag to the digest of its manifest by a HEAD request,
+// it falls back to GET when the registry does not return Docker-Content-Digest on HEAD.
+// A digest reference is returned as is.
+// end-3	HEAD	/v2/<name>/manifests/<reference>	200	404
+func (c *OCIDistributionClient) ResolveDigest(ctx context.Context, name, tag string) (digest.Digest, error) {
+	if dgst, err := digest.Parse(tag); err == nil {
+		return dgst, nil
+	}
+	resp, err := c.manifestRequest(ctx, http.MethodHead, name, tag, nil)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if header := resp.Header.Get("Docker-Content-Digest"); header != "" {
+		return digest.Parse(header)
+	}
+	manifest, err := c.GetManifestRaw(ctx, name, tag)
+	if err != nil {
+		return "", err
+	}
+	return manifest.Digest, nil
+}
+
+// PinImage rewrites the image reference to the digest form,
+// e.g. "harbor.example.com/proj/app:v1" to "harbor.example.com/proj/app@sha256:...".
+// The client must be the registry of the image.
+func (c *OCIDistributionClient) PinImage(ctx context.Context, image string) (string, error) {
+	domain, path, name, tag, err := ParseImag(image)
+	if err != nil {
+		return "", err
+	}
+	repository := path + "/" + name
+	dgst, err := c.ResolveDigest(ctx, repository, tag)
+	if err != nil {
+		return "", err
+	}
+	return domain + "/" + repository + "@" + dgst.String(), nil
+}
+
+// PinnedImage is the result of PinImages
+type PinnedImage struct {
+	Image  string // the original reference
+	Pinned string // the digest form reference,empty if Err
+	Err    error
+}
+
+// PinImages pins images concurrently with at most 'concurrency' requests in flight,
+// results are in the order of images.
+func (c *OCIDistributionClient) PinImages(ctx context.Context, images []string, concurrency int) []PinnedImage {
+	if concurrency <= 0 {
+		concurrency = 8
+	}
+	results := make([]PinnedImage, len(images))
+	sem := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+	for i, image := range images {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, image string) {
+			defer func() { <-sem; wg.Done() }()
+			pinned, err := c.PinImage(ctx, image)
+			results[i] = PinnedImage{Image: image, Pinned: pinned, Err: err}
+		}(i, image)
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+	"testing"
+)
+
+func TestOCIDistributionClient_PinImages(t *testing.T) {
+	registry, cli := newFakeRegistry(t)
+	v1 := pushTestImage(registry, "proj/app", "v1")
+	v2 := pushTestImage(registry, "proj/app", "v2")
+	nested := pushTestImage(registry, "proj/team/api", "latest")
+
+	images := []string{
+		"harbor.example.com/proj/app:v1",
+		"harbor.example.com/proj/app:v2",
+		"harbor.example.com/proj/team/api",
+		"harbor.example.com/proj/app@" + v1.String(),
+		"harbor.example.com/proj/app:missing",
+	}
+	want := []string{
+		"harbor.example.com/proj/app@" + v1.String(),
+		"harbor.example.com/proj/app@" + v2.String(),
+		"harbor.example.com/proj/team/api@" + nested.String(),
+		"harbor.example.com/proj/app@" + v1.String(),
+		"",
+	}
+	results := cli.PinImages(context.Background(), images, 2)
+	for i, result := range results {
+		if result.Image != images[i] || result.Pinned != want[i] {
+			t.Errorf("PinImages()[%d] = %+v, want %s", i, result, want[i])
+		}
+		if (result.Err != nil) != (want[i] == "") {
+			t.Errorf("PinImages()[%d] error = %v", i, result.Err)
+		}
+	}
+	if !IsOCINotFound(results[4].Err) {
+		t.Errorf("PinImages() error = %v, want not found", results[4].Err)
+	}
+}
@@ -0,0 +1,271 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	imagespecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// ErrPlatformNotFound is returned when no manifest matches the platform
+var ErrPlatformNotFound = errors.New("no matching manifest for platform")
+
+// ParsePlatform parses the platform specifier in the format "<os>/<arch>[/<variant>]",e.g. "linux/arm64/v8".
+func ParsePlatform(specifier string) (imagespecv1.Platform, error) {
+	parts := strings.Split(specifier, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return imagespecv1.Platform{}, fmt.Errorf("invalid platform %q: must be <os>/<arch>[/<variant>]", specifier)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return imagespecv1.Platform{}, fmt.Errorf("invalid platform %q: empty component", specifier)
+		}
+	}
+	platform := imagespecv1.Platform{OS: parts[0], Architecture: parts[1]}
+	if len(parts) == 3 {
+		platform.Variant = parts[2]
+	}
+	return NormalizePlatform(platform), nil
+}
+
+// FormatPlatform returns the specifier of platform,e.g. "linux/arm/v7"
+func FormatPlatform(platform imagespecv1.Platform) string {
+	if platform.OS == "" {
+		return "unknown"
+	}
+	return path.Join(platform.OS, platform.Architecture, platform.Variant)
+}
+
+// NormalizePlatform translates the platform to the canonical value as containerd does,
+// e.g. "aarch64" to "arm64","x86_64" to "amd64".An empty os is the os of current process.
+func NormalizePlatform(platform imagespecv1.Platform) imagespecv1.Platform {
+	platform.OS = strings.ToLower(platform.OS)
+	switch platform.OS {
+	case "":
+		platform.OS = runtime.GOOS
+	case "macos":
+		platform.OS = "darwin"
+	}
+	arch, variant := strings.ToLower(platform.Architecture), strings.ToLower(platform.Variant)
+	switch arch {
+	case "i386":
+		arch, variant = "386", ""
+	case "x86_64", "x86-64", "amd64":
+		arch = "amd64"
+		if variant == "v1" {
+			variant = ""
+		}
+	case "aarch64", "arm64":
+		arch = "arm64"
+		if variant == "8" || variant == "v8" {
+			variant = ""
+		}
+	case "armhf":
+		arch, variant = "arm", "v7"
+	case "armel":
+		arch, variant = "arm", "v6"
+	case "arm":
+		switch variant {
+		case "", "7":
+			variant = "v7"
+		case "5", "6", "8":
+			variant = "v" + variant
+		}
+	}
+	platform.Architecture, platform.Variant = arch, variant
+	return platform
+}
+
+// PlatformMatcher matches platforms with the rules of containerd 'platforms.Only':
+// arm/v8 also matches arm/v7,arm/v6 and arm/v5,arm64 also matches arm/v8 and lower,
+// amd64/vN also matches amd64 of lower versions and 386.
+// If the wanted platform has an os.version,only platforms with the os.version prefix are matched,
+// the one with larger revision is preferred,like containerd on windows.
+type PlatformMatcher struct {
+	wanted imagespecv1.Platform
+	vector []imagespecv1.Platform // ordered by preference
+}
+
+func NewPlatformMatcher(wanted imagespecv1.Platform) *PlatformMatcher {
+	wanted = NormalizePlatform(wanted)
+	return &PlatformMatcher{wanted: wanted, vector: platformVector(wanted)}
+}
+
+// Match reports whether the platform can run on the wanted platform
+func (m *PlatformMatcher) Match(platform imagespecv1.Platform) bool {
+	return m.rank(platform) >= 0
+}
+
+// Less reports whether p1 is preferred to p2,matched platforms are in front of others.
+func (m *PlatformMatcher) Less(p1, p2 imagespecv1.Platform) bool {
+	r1, r2 := m.rank(p1), m.rank(p2)
+	switch {
+	case r1 < 0:
+		return false
+	case r2 < 0:
+		return true
+	case r1 != r2:
+		return r1 < r2
+	default:
+		return osRevision(p1.OSVersion) > osRevision(p2.OSVersion)
+	}
+}
+
+// rank returns the preference of platform in vector,-1 if not matched
+func (m *PlatformMatcher) rank(platform imagespecv1.Platform) int {
+	if m.wanted.OSVersion != "" && !strings.HasPrefix(platform.OSVersion, m.wanted.OSVersion) {
+		return -1
+	}
+	normalized := NormalizePlatform(platform)
+	for i, candidate := range m.vector {
+		if candidate.OS == normalized.OS && candidate.Architecture == normalized.Architecture && candidate.Variant == normalized.Variant {
+			return i
+		}
+	}
+	return -1
+}
+
+// platformVector returns the platforms can run on the platform in order of preference
+func platformVector(platform imagespecv1.Platform) []imagespecv1.Platform {
+	vector := []imagespecv1.Platform{platform}
+	with := func(arch, variant string) imagespecv1.Platform {
+		return imagespecv1.Platform{OS: platform.OS, OSVersion: platform.OSVersion, Architecture: arch, Variant: variant}
+	}
+	switch platform.Architecture {
+	case "amd64":
+		if version, err := strconv.Atoi(strings.TrimPrefix(platform.Variant, "v")); err == nil && version > 1 {
+			for version--; version >= 1; version-- {
+				vector = append(vector, NormalizePlatform(with("amd64", "v"+strconv.Itoa(version))))
+			}
+		}
+		vector = append(vector, with("386", ""))
+	case "arm":
+		if version, err := strconv.Atoi(strings.TrimPrefix(platform.Variant, "v")); err == nil && version > 5 {
+			for version--; version >= 5; version-- {
+				vector = append(vector, with("arm", "v"+strconv.Itoa(version)))
+			}
+		}
+	case "arm64":
+		variant := platform.Variant
+		if variant == "" {
+			variant = "v8"
+		}
+		vector = append(vector, platformVector(with("arm", variant))...)
+	}
+	return vector
+}
+
+// osRevision returns the revision of windows os.version,e.g. 1234 of "10.0.17763.1234"
+func osRevision(version string) int {
+	parts := strings.Split(version, ".")
+	if len(parts) < 4 {
+		return 0
+	}
+	revision, _ := strconv.Atoi(parts[3])
+	return revision
+}
+
+// PlatformManifest is an image manifest resolved for a platform
+type PlatformManifest struct {
+	Platform imagespecv1.Platform
+	Index    *RawManifest // the index resolved from,nil if the reference is a single manifest
+	Manifest *RawManifest
+	Config   []byte // the raw image config
+}
+
+// imagePlatformConfig is the platform fields of image config
+type imagePlatformConfig struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	OSVersion    string `json:"os.version,omitempty"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// ResolvePlatform resolves the image index or docker manifest list to the manifest of the platform,
+// it returns the chosen manifest and its config.
+// A single manifest is returned if its config matches the platform.
+// ErrPlatformNotFound is returned if no manifest matches.
+func (c *OCIDistributionClient) ResolvePlatform(ctx context.Context, name, reference string, platform imagespecv1.Platform) (*PlatformManifest, error) {
+	matcher := NewPlatformMatcher(platform)
+	manifest, err := c.GetManifestRaw(ctx, name, reference)
+	if err != nil {
+		return nil, err
+	}
+	resolved := &PlatformManifest{}
+	if manifest.IsIndex() {
+		index := &imagespecv1.Index{}
+		if err := json.Unmarshal(manifest.Content, index); err != nil {
+			return nil, err
+		}
+		candidates := []imagespecv1.Descriptor{}
+		for _, descriptor := range index.Manifests {
+			if descriptor.Platform != nil && matcher.Match(*descriptor.Platform) {
+				candidates = append(candidates, descriptor)
+			}
+		}
+		if len(candidates) == 0 {
+			return nil, fmt.Errorf("%w %s in %s:%s", ErrPlatformNotFound, FormatPlatform(matcher.wanted), name, reference)
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return matcher.Less(*candidates[i].Platform, *candidates[j].Platform)
+		})
+		chosen := candidates[0]
+		resolved.Index, resolved.Platform = manifest, *chosen.Platform
+		if manifest, err = c.GetManifestRaw(ctx, name, chosen.Digest.String()); err != nil {
+			return nil, err
+		}
+	}
+	resolved.Manifest = manifest
+
+	image := &imagespecv1.Manifest{}
+	if err := json.Unmarshal(manifest.Content, image); err != nil {
+		return nil, err
+	}
+	if resolved.Config, err = c.getBlobBytes(ctx, name, image.Config.Digest); err != nil {
+		return nil, fmt.Errorf("get config of %s: %w", manifest.Digest, err)
+	}
+	if resolved.Index == nil {
+		config := imagePlatformConfig{}
+		if err := json.Unmarshal(resolved.Config, &config); err != nil {
+			return nil, err
+		}
+		resolved.Platform = imagespecv1.Platform{
+			Architecture: config.Architecture, OS: config.OS, OSVersion: config.OSVersion, Variant: config.Variant,
+		}
+		if !matcher.Match(resolved.Platform) {
+			return nil, fmt.Errorf("%w %s: %s:%s is %s", ErrPlatformNotFound, FormatPlatform(matcher.wanted), name, reference, FormatPlatform(resolved.Platform))
+		}
+	}
+	return resolved, nil
+}
+
+// ListArtifactPlatforms lists the platforms of a harbor artifact,
+// the platforms of child manifests for an index,or the platform in image config for an image.
+func (c *Client) ListArtifactPlatforms(ctx context.Context, project, repository, reference string) ([]imagespecv1.Platform, error) {
+	artifact, err := c.GetArtifact(ctx, project, repository, reference, GetArtifactOptions{})
+	if err != nil {
+		return nil, err
+	}
+	platforms := []imagespecv1.Platform{}
+	for _, ref := range artifact.References {
+		if ref != nil && ref.Platform != nil {
+			platforms = append(platforms, *ref.Platform)
+		}
+	}
+	if len(artifact.References) > 0 {
+		return platforms, nil
+	}
+	os, _ := artifact.ExtraAttrs["os"].(string)
+	arch, _ := artifact.ExtraAttrs["architecture"].(string)
+	if os != "" || arch != "" {
+		platforms = append(platforms, imagespecv1.Platform{OS: os, Architecture: arch})
+	}
+	return platforms, nil
+}
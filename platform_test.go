@@ -0,0 +1,152 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goharbor/harbor/src/pkg/artifact"
+	"github.com/opencontainers/image-spec/specs-go"
+	imagespecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestPlatformMatcher(t *testing.T) {
+	tests := []struct {
+		wanted   string
+		platform imagespecv1.Platform
+		want     bool
+	}{
+		{wanted: "linux/amd64", platform: imagespecv1.Platform{OS: "linux", Architecture: "x86_64"}, want: true},
+		{wanted: "linux/amd64", platform: imagespecv1.Platform{OS: "linux", Architecture: "386"}, want: true},
+		{wanted: "linux/amd64", platform: imagespecv1.Platform{OS: "linux", Architecture: "amd64", Variant: "v2"}, want: false},
+		{wanted: "linux/amd64/v3", platform: imagespecv1.Platform{OS: "linux", Architecture: "amd64", Variant: "v2"}, want: true},
+		{wanted: "linux/amd64/v3", platform: imagespecv1.Platform{OS: "linux", Architecture: "amd64"}, want: true},
+		{wanted: "linux/arm64", platform: imagespecv1.Platform{OS: "linux", Architecture: "aarch64"}, want: true},
+		{wanted: "linux/arm64", platform: imagespecv1.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}, want: true},
+		{wanted: "linux/arm64", platform: imagespecv1.Platform{OS: "linux", Architecture: "arm", Variant: "v7"}, want: true},
+		{wanted: "linux/arm/v6", platform: imagespecv1.Platform{OS: "linux", Architecture: "arm", Variant: "v7"}, want: false},
+		{wanted: "linux/arm/v7", platform: imagespecv1.Platform{OS: "linux", Architecture: "armel"}, want: true},
+		{wanted: "linux/arm64", platform: imagespecv1.Platform{OS: "windows", Architecture: "arm64"}, want: false},
+	}
+	for _, tt := range tests {
+		wanted, err := ParsePlatform(tt.wanted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := NewPlatformMatcher(wanted).Match(tt.platform); got != tt.want {
+			t.Errorf("%s matches %s = %v, want %v", tt.wanted, FormatPlatform(tt.platform), got, tt.want)
+		}
+	}
+}
+
+func TestPlatformMatcher_Less(t *testing.T) {
+	matcher := NewPlatformMatcher(imagespecv1.Platform{OS: "linux", Architecture: "arm64"})
+	arm64 := imagespecv1.Platform{OS: "linux", Architecture: "arm64"}
+	armv7 := imagespecv1.Platform{OS: "linux", Architecture: "arm", Variant: "v7"}
+	amd64 := imagespecv1.Platform{OS: "linux", Architecture: "amd64"}
+	if !matcher.Less(arm64, armv7) || matcher.Less(armv7, arm64) || !matcher.Less(armv7, amd64) {
+		t.Error("arm64 should be preferred to arm/v7,arm/v7 to amd64")
+	}
+
+	windows := NewPlatformMatcher(imagespecv1.Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.17763"})
+	old := imagespecv1.Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.17763.1000"}
+	patched := imagespecv1.Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.17763.2000"}
+	other := imagespecv1.Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.20348.100"}
+	if windows.Match(other) || !windows.Less(patched, old) {
+		t.Error("windows os.version should be matched by prefix and larger revision preferred")
+	}
+}
+
+func TestOCIDistributionClient_ResolvePlatform(t *testing.T) {
+	registry, cli := newFakeRegistry(t)
+	ctx := context.Background()
+	pushPlatform := func(platform imagespecv1.Platform) imagespecv1.Descriptor {
+		config, _ := json.Marshal(imagePlatformConfig{OS: platform.OS, Architecture: platform.Architecture, Variant: platform.Variant})
+		configDigest := registry.putBlob("library/app", config)
+		manifest := imagespecv1.Manifest{
+			Versioned: specs.Versioned{SchemaVersion: 2},
+			MediaType: imagespecv1.MediaTypeImageManifest,
+			Config:    imagespecv1.Descriptor{MediaType: imagespecv1.MediaTypeImageConfig, Digest: configDigest, Size: int64(len(config))},
+		}
+		dgst := registry.putManifest("library/app", imagespecv1.MediaTypeImageManifest, manifest)
+		return imagespecv1.Descriptor{MediaType: imagespecv1.MediaTypeImageManifest, Digest: dgst, Platform: &platform}
+	}
+	amd64 := pushPlatform(imagespecv1.Platform{OS: "linux", Architecture: "amd64"})
+	armv7 := pushPlatform(imagespecv1.Platform{OS: "linux", Architecture: "arm", Variant: "v7"})
+	arm64 := pushPlatform(imagespecv1.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"})
+	registry.putManifest("library/app", MediaTypeDockerManifestList, imagespecv1.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: MediaTypeDockerManifestList,
+		Manifests: []imagespecv1.Descriptor{amd64, armv7, arm64},
+	}, "v1")
+
+	tests := []struct {
+		reference string
+		platform  string
+		want      imagespecv1.Descriptor
+		index     bool
+	}{
+		{reference: "v1", platform: "linux/amd64", want: amd64, index: true},
+		{reference: "v1", platform: "linux/arm64", want: arm64, index: true},
+		{reference: "v1", platform: "linux/arm/v8", want: armv7, index: true},
+		{reference: amd64.Digest.String(), platform: "linux/amd64", want: amd64},
+	}
+	for _, tt := range tests {
+		platform, _ := ParsePlatform(tt.platform)
+		resolved, err := cli.ResolvePlatform(ctx, "library/app", tt.reference, platform)
+		if err != nil {
+			t.Fatalf("ResolvePlatform(%s, %s) error = %v", tt.reference, tt.platform, err)
+		}
+		if resolved.Manifest.Digest != tt.want.Digest || (resolved.Index != nil) != tt.index || len(resolved.Config) == 0 {
+			t.Errorf("ResolvePlatform(%s, %s) = %s", tt.reference, tt.platform, resolved.Manifest.Digest)
+		}
+		if !NewPlatformMatcher(*tt.want.Platform).Match(resolved.Platform) {
+			t.Errorf("ResolvePlatform(%s, %s) platform = %s", tt.reference, tt.platform, FormatPlatform(resolved.Platform))
+		}
+	}
+
+	for _, reference := range []string{"v1", amd64.Digest.String()} {
+		_, err := cli.ResolvePlatform(ctx, "library/app", reference, imagespecv1.Platform{OS: "linux", Architecture: "s390x"})
+		if !errors.Is(err, ErrPlatformNotFound) {
+			t.Errorf("ResolvePlatform(%s) error = %v, want ErrPlatformNotFound", reference, err)
+		}
+	}
+}
+
+func TestClient_ListArtifactPlatforms(t *testing.T) {
+	artifacts := map[string]Artifact{
+		"index": {Artifact: artifact.Artifact{References: []*artifact.Reference{
+			{Platform: &imagespecv1.Platform{OS: "linux", Architecture: "amd64"}},
+			{Platform: &imagespecv1.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}},
+		}}},
+		"image": {Artifact: artifact.Artifact{ExtraAttrs: map[string]interface{}{"os": "linux", "architecture": "amd64"}}},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for reference, artifact := range artifacts {
+			if r.URL.Path == "/api/v2.0/projects/library/repositories/app/artifacts/"+reference {
+				_ = json.NewEncoder(w).Encode(artifact)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+	cli, _ := NewClient(server.URL)
+
+	for reference, want := range map[string][]string{"index": {"linux/amd64", "linux/arm64/v8"}, "image": {"linux/amd64"}} {
+		platforms, err := cli.ListArtifactPlatforms(context.Background(), "library", "app", reference)
+		if err != nil {
+			t.Fatalf("ListArtifactPlatforms(%s) error = %v", reference, err)
+		}
+		got := []string{}
+		for _, platform := range platforms {
+			got = append(got, FormatPlatform(platform))
+		}
+		if !equalStrings(got, want) {
+			t.Errorf("ListArtifactPlatforms(%s) = %v, want %v", reference, got, want)
+		}
+	}
+}
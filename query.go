@@ -0,0 +1,137 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueryBuilder builds the harbor query string 'q'.
+//
+// Supported query patterns are "exact match(k=v)", "fuzzy match(k=~v)","range(k=[min~max])",
+// "list with union releationship(k={v1 v2 v3})" and"list with intersetion relationship(k=(v1 v2 v3))".
+// Values are rendered by type: integer as is,time.Time in format "2020-04-09T02:36:00" of UTC,
+// string as is in exact match and enclosed by " in range and list,harbor trims the quotes there.
+//
+// Harbor splits 'q' by ',' and lists by ' ' before parse values,
+// so string values contain ',' or ' '(in list) are not supported by harbor.
+// Harbor parses range and list values as time or integer only,other values are compared as string.
+//
+// e.g. Query().Eq("name", "nginx").Fuzzy("tags", "v1").Range("push_time", from, to).In("labels", 1, 2)
+type QueryBuilder struct {
+	conditions []string
+}
+
+// harbor query time format
+const queryTimeFormat = "2006-01-02T15:04:05"
+
+func Query() *QueryBuilder {
+	return &QueryBuilder{}
+}
+
+// Eq exact match: k=v
+// harbor takes the value literally,a value starts with a pattern character is escaped by '\'.
+func (q *QueryBuilder) Eq(key string, value interface{}) *QueryBuilder {
+	formatted := formatQueryValue(value, false)
+	if formatted != "" && strings.ContainsRune(`~[{(\`, rune(formatted[0])) {
+		formatted = `\` + formatted
+	}
+	return q.add(key, formatted)
+}
+
+// Fuzzy fuzzy match: k=~v
+// harbor use the value as is in fuzzy match,so it is never quoted.
+func (q *QueryBuilder) Fuzzy(key string, value string) *QueryBuilder {
+	return q.add(key, "~"+value)
+}
+
+// Range range match: k=[min~max]
+// nil min or max means no limit,so does a nil *time.Time.
+func (q *QueryBuilder) Range(key string, min, max interface{}) *QueryBuilder {
+	minstr, maxstr := "", ""
+	if min != nil {
+		minstr = formatQueryValue(min, true)
+	}
+	if max != nil {
+		maxstr = formatQueryValue(max, true)
+	}
+	return q.add(key, "["+minstr+"~"+maxstr+"]")
+}
+
+// In list with union relationship: k={v1 v2 v3}
+func (q *QueryBuilder) In(key string, values ...interface{}) *QueryBuilder {
+	return q.add(key, "{"+formatQueryValues(values)+"}")
+}
+
+// All list with intersection relationship: k=(v1 v2 v3)
+func (q *QueryBuilder) All(key string, values ...interface{}) *QueryBuilder {
+	return q.add(key, "("+formatQueryValues(values)+")")
+}
+
+func (q *QueryBuilder) add(key, value string) *QueryBuilder {
+	q.conditions = append(q.conditions, key+"="+value)
+	return q
+}
+
+// String returns the rendered 'q',e.g. name=nginx,tags=~v1
+func (q *QueryBuilder) String() string {
+	if q == nil {
+		return ""
+	}
+	return strings.Join(q.conditions, ",")
+}
+
+func formatQueryValues(values []interface{}) string {
+	formatted := make([]string, 0, len(values))
+	for _, value := range values {
+		formatted = append(formatted, formatQueryValue(value, true))
+	}
+	return strings.Join(formatted, " ")
+}
+
+// formatQueryValue renders the value,strings are quoted if quote
+func formatQueryValue(value interface{}, quote bool) string {
+	switch typed := value.(type) {
+	case string:
+		return quoteQueryString(typed, quote)
+	case time.Time:
+		return typed.UTC().Format(queryTimeFormat)
+	case *time.Time:
+		if typed == nil {
+			return ""
+		}
+		return typed.UTC().Format(queryTimeFormat)
+	case int:
+		return strconv.Itoa(typed)
+	case int32:
+		return strconv.FormatInt(int64(typed), 10)
+	case int64:
+		return strconv.FormatInt(typed, 10)
+	case uint:
+		return strconv.FormatUint(uint64(typed), 10)
+	case uint32:
+		return strconv.FormatUint(uint64(typed), 10)
+	case uint64:
+		return strconv.FormatUint(typed, 10)
+	case float32:
+		return strconv.FormatFloat(float64(typed), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(typed)
+	case fmt.Stringer:
+		return quoteQueryString(typed.String(), quote)
+	default:
+		return quoteQueryString(fmt.Sprint(typed), quote)
+	}
+}
+
+// quoteQueryString encloses the string by " if quote,
+// it keeps a string like "1" from being parsed as integer in range and list.
+func quoteQueryString(s string, quote bool) string {
+	if !quote {
+		return s
+	}
+	return `"` + s + `"`
+}
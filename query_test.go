@@ -0,0 +1,91 @@
+package client
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestQueryBuilder(t *testing.T) {
+	from := time.Date(2020, 4, 9, 2, 36, 0, 0, time.UTC)
+	to := time.Date(2020, 4, 10, 10, 36, 0, 0, time.FixedZone("UTC+8", 8*60*60))
+	tests := []struct {
+		name  string
+		query *QueryBuilder
+		want  string
+	}{
+		{name: "exact", query: Query().Eq("name", "nginx").Eq("project_id", 1), want: `name=nginx,project_id=1`},
+		{name: "literal", query: Query().Eq("description", `say "hi"`), want: `description=say "hi"`},
+		{name: "escape", query: Query().Eq("name", "~nginx").Eq("path", `\tmp`), want: `name=\~nginx,path=\\tmp`},
+		{name: "fuzzy", query: Query().Fuzzy("tags", "v1"), want: `tags=~v1`},
+		{name: "range", query: Query().Range("push_time", from, to), want: `push_time=[2020-04-09T02:36:00~2020-04-10T02:36:00]`},
+		{name: "open range", query: Query().Range("cvss_score_v3", 7, nil), want: `cvss_score_v3=[7~]`},
+		{name: "nil time", query: Query().Range("push_time", (*time.Time)(nil), &from), want: `push_time=[~2020-04-09T02:36:00]`},
+		{name: "union", query: Query().In("labels", 1, 2, 3), want: `labels={1 2 3}`},
+		{name: "intersection", query: Query().All("severity", "High", "Critical"), want: `severity=("High" "Critical")`},
+		{name: "empty", query: Query(), want: ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.query.String(); got != tt.want {
+				t.Errorf("QueryBuilder.String() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommonListOptions_toQuery(t *testing.T) {
+	options := CommonListOptions{Page: 1, Size: 10, Query: Query().Eq("name", "a+b"), Sort: "-creation_time"}
+	values := options.toQuery()
+	if values.Get("sort") != "-creation_time" {
+		t.Errorf("sort = %s", values.Get("sort"))
+	}
+	// harbor unescapes q after the query decoded
+	q, err := url.QueryUnescape(values.Get("q"))
+	if err != nil || q != `name=a+b` {
+		t.Errorf("q = %s, err = %v", q, err)
+	}
+}
+
+func TestSearchVulnerabilitiesOptions_toQuery(t *testing.T) {
+	options := SearchVulnerabilitiesOptions{
+		CommonListOptions: CommonListOptions{Page: 2, Size: 50},
+		CVEID:             "CVE-2021-44228",
+		Package:           "log4j-core",
+		Severity:          "Critical",
+		ProjectID:         3,
+		RepositoryName:    "library/app",
+		Digest:            "sha256:abc",
+		Tag:               "v1",
+		CVSSScoreMin:      7,
+		CVSSScoreMax:      9,
+		WithTag:           true,
+	}
+	values := options.toQuery()
+	q, _ := url.QueryUnescape(values.Get("q"))
+	want := `cve_id=CVE-2021-44228,package=log4j-core,severity=Critical,repository_name=library/app,digest=sha256:abc,tag=v1,project_id=3,cvss_score_v3=[7~9]`
+	if q != want {
+		t.Errorf("q = %s, want %s", q, want)
+	}
+	if values.Get("page") != "2" || values.Get("with_tag") != "true" {
+		t.Errorf("values = %v", values)
+	}
+}
+
+func TestListAccessoriesOptions_toQuery(t *testing.T) {
+	tests := []struct {
+		types []AccessoryType
+		want  string
+	}{
+		{types: nil, want: ``},
+		{types: []AccessoryType{AccessoryTypeCosignSignature}, want: `type={"signature.cosign"}`},
+		{types: []AccessoryType{AccessoryTypeCosignSignature, AccessoryTypeNotationSignature}, want: `type={"signature.cosign" "signature.notation"}`},
+	}
+	for _, tt := range tests {
+		options := ListAccessoriesOptions{Types: tt.types}
+		q, _ := url.QueryUnescape(options.toQuery().Get("q"))
+		if q != tt.want {
+			t.Errorf("q = %s, want %s", q, tt.want)
+		}
+	}
+}
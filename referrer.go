@@ -0,0 +1,236 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	imagespecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/tomnomnom/linkheader"
+)
+
+// MediaTypeEmptyJSON is the media type of the empty config "{}" of artifacts
+const MediaTypeEmptyJSON = "application/vnd.oci.empty.v1+json"
+
+// ReferrerDescriptor is a descriptor of image-spec v1.1 with the artifact type
+type ReferrerDescriptor struct {
+	imagespecv1.Descriptor
+	ArtifactType string `json:"artifactType,omitempty"`
+}
+
+// ReferrerIndex is the image index of referrers
+type ReferrerIndex struct {
+	specs.Versioned
+	MediaType   string               `json:"mediaType"`
+	Manifests   []ReferrerDescriptor `json:"manifests"`
+	Annotations map[string]string    `json:"annotations,omitempty"`
+}
+
+// ReferrerManifest is an image manifest of image-spec v1.1 refers to a subject
+type ReferrerManifest struct {
+	specs.Versioned
+	MediaType    string                   `json:"mediaType"`
+	ArtifactType string                   `json:"artifactType,omitempty"`
+	Config       imagespecv1.Descriptor   `json:"config"`
+	Layers       []imagespecv1.Descriptor `json:"layers"`
+	Subject      *imagespecv1.Descriptor  `json:"subject,omitempty"`
+	Annotations  map[string]string        `json:"annotations,omitempty"`
+}
+
+// ArtifactBlob is a file of the artifact pushed by PushReferrer
+type ArtifactBlob struct {
+	MediaType   string
+	Content     []byte
+	Annotations map[string]string
+}
+
+// ReferrersTag returns the tag of referrers index in the tag schema,e.g. "sha256-<hex>" for "sha256:<hex>".
+func ReferrersTag(dgst digest.Digest) string {
+	return fmt.Sprintf("%s-%s", dgst.Algorithm(), dgst.Encoded())
+}
+
+// ListReferrers lists the manifests refer to the subject digest,e.g. signatures,sboms and attestations.
+// artifactType filters the referrers if not empty.
+// It falls back to the referrers tag schema if the registry does not support the referrers api.
+// end-12a	GET	/v2/<name>/referrers/<digest>	200	404/400
+// end-12b	GET	/v2/<name>/referrers/<digest>?artifactType=<artifactType>	200	404/400
+func (c *OCIDistributionClient) ListReferrers(ctx context.Context, name string, dgst digest.Digest, artifactType string) ([]ReferrerDescriptor, error) {
+	if err := dgst.Validate(); err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	if artifactType != "" {
+		query.Set("artifactType", artifactType)
+	}
+	next := "/v2/" + name + "/referrers/" + dgst.String() + "?" + query.Encode()
+	referrers := []ReferrerDescriptor{}
+	filtered := true
+	for next != "" {
+		index, resp, err := c.getReferrersPage(ctx, next)
+		if err != nil {
+			if IsOCINotFound(err) && len(referrers) == 0 {
+				return c.listReferrersByTag(ctx, name, dgst, artifactType)
+			}
+			return nil, err
+		}
+		filtered = filtered && resp.Header.Get("OCI-Filters-Applied") != ""
+		referrers = append(referrers, index.Manifests...)
+		next = ""
+		for _, link := range linkheader.Parse(resp.Header.Get("Link")).FilterByRel("next") {
+			next = link.URL
+		}
+	}
+	if artifactType != "" && !filtered {
+		referrers = filterReferrers(referrers, artifactType)
+	}
+	return referrers, nil
+}
+
+func (c *OCIDistributionClient) getReferrersPage(ctx context.Context, location string) (*ReferrerIndex, *http.Response, error) {
+	u, err := c.resolve(location)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Accept", imagespecv1.MediaTypeImageIndex)
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	index := &ReferrerIndex{}
+	if err := json.NewDecoder(resp.Body).Decode(index); err != nil {
+		return nil, nil, err
+	}
+	return index, resp, nil
+}
+
+// listReferrersByTag lists referrers in the index of referrers tag schema
+func (c *OCIDistributionClient) listReferrersByTag(ctx context.Context, name string, dgst digest.Digest, artifactType string) ([]ReferrerDescriptor, error) {
+	index, err := c.getReferrersTagIndex(ctx, name, dgst)
+	if err != nil {
+		return nil, err
+	}
+	if artifactType != "" {
+		return filterReferrers(index.Manifests, artifactType), nil
+	}
+	return index.Manifests, nil
+}
+
+// getReferrersTagIndex returns the index of referrers tag schema,an empty index if not exists.
+func (c *OCIDistributionClient) getReferrersTagIndex(ctx context.Context, name string, dgst digest.Digest) (*ReferrerIndex, error) {
+	index := &ReferrerIndex{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: imagespecv1.MediaTypeImageIndex,
+		Manifests: []ReferrerDescriptor{},
+	}
+	manifest, err := c.GetManifestRaw(ctx, name, ReferrersTag(dgst), imagespecv1.MediaTypeImageIndex)
+	if err != nil {
+		if IsOCINotFound(err) {
+			return index, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(manifest.Content, index); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+func filterReferrers(referrers []ReferrerDescriptor, artifactType string) []ReferrerDescriptor {
+	filtered := []ReferrerDescriptor{}
+	for _, referrer := range referrers {
+		if referrer.ArtifactType == artifactType {
+			filtered = append(filtered, referrer)
+		}
+	}
+	return filtered
+}
+
+// PushReferrer pushes an artifact of artifactType refers to the subject,
+// blobs are the files of the artifact,they are not uploaded again if exist.
+// If the registry does not support the referrers api,the referrers index of tag schema is updated.
+func (c *OCIDistributionClient) PushReferrer(ctx context.Context, name string, subject imagespecv1.Descriptor, artifactType string, blobs []ArtifactBlob, annotations map[string]string) (ReferrerDescriptor, error) {
+	empty := []byte("{}")
+	config := imagespecv1.Descriptor{MediaType: MediaTypeEmptyJSON, Digest: digest.FromBytes(empty), Size: int64(len(empty))}
+	if err := c.pushBlobIfNotExists(ctx, name, config, empty); err != nil {
+		return ReferrerDescriptor{}, err
+	}
+	manifest := ReferrerManifest{
+		Versioned:    specs.Versioned{SchemaVersion: 2},
+		MediaType:    imagespecv1.MediaTypeImageManifest,
+		ArtifactType: artifactType,
+		Config:       config,
+		Layers:       []imagespecv1.Descriptor{},
+		Subject:      &imagespecv1.Descriptor{MediaType: subject.MediaType, Digest: subject.Digest, Size: subject.Size},
+		Annotations:  annotations,
+	}
+	// an artifact without files has the empty layer
+	if len(blobs) == 0 {
+		manifest.Layers = append(manifest.Layers, config)
+	}
+	for _, blob := range blobs {
+		descriptor := imagespecv1.Descriptor{
+			MediaType:   blob.MediaType,
+			Digest:      digest.FromBytes(blob.Content),
+			Size:        int64(len(blob.Content)),
+			Annotations: blob.Annotations,
+		}
+		if err := c.pushBlobIfNotExists(ctx, name, descriptor, blob.Content); err != nil {
+			return ReferrerDescriptor{}, err
+		}
+		manifest.Layers = append(manifest.Layers, descriptor)
+	}
+
+	content, err := json.Marshal(manifest)
+	if err != nil {
+		return ReferrerDescriptor{}, err
+	}
+	descriptor, header, err := c.putManifest(ctx, name, digest.FromBytes(content).String(), manifest.MediaType, content)
+	if err != nil {
+		return ReferrerDescriptor{}, err
+	}
+	descriptor.Annotations = annotations
+	referrer := ReferrerDescriptor{Descriptor: descriptor, ArtifactType: artifactType}
+	// the registry supports the referrers api
+	if header.Get("OCI-Subject") != "" {
+		return referrer, nil
+	}
+	return referrer, c.addReferrerToTagIndex(ctx, name, subject.Digest, referrer)
+}
+
+func (c *OCIDistributionClient) addReferrerToTagIndex(ctx context.Context, name string, subject digest.Digest, referrer ReferrerDescriptor) error {
+	index, err := c.getReferrersTagIndex(ctx, name, subject)
+	if err != nil {
+		return err
+	}
+	for _, exists := range index.Manifests {
+		if exists.Digest == referrer.Digest {
+			return nil
+		}
+	}
+	index.Manifests = append(index.Manifests, referrer)
+	content, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	_, err = c.PutManifest(ctx, name, ReferrersTag(subject), imagespecv1.MediaTypeImageIndex, content)
+	return err
+}
+
+func (c *OCIDistributionClient) pushBlobIfNotExists(ctx context.Context, name string, descriptor imagespecv1.Descriptor, content []byte) error {
+	if _, err := c.HeadBlob(ctx, name, descriptor.Digest); err == nil {
+		return nil
+	} else if !IsOCINotFound(err) {
+		return err
+	}
+	return c.PushBlob(ctx, name, descriptor.Digest, descriptor.Size, bytes.NewReader(content))
+}
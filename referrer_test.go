@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	imagespecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestOCIDistributionClient_Referrers(t *testing.T) {
+	const (
+		sbomType        = "application/spdx+json"
+		attestationType = "application/vnd.in-toto+json"
+	)
+	for name, referrersAPI := range map[string]bool{"referrers api": true, "tag schema": false} {
+		t.Run(name, func(t *testing.T) {
+			registry, cli := newFakeRegistry(t)
+			registry.referrers = referrersAPI
+			ctx := context.Background()
+			image := pushTestImage(registry, "library/app", "v1")
+			subject := imagespecv1.Descriptor{MediaType: imagespecv1.MediaTypeImageManifest, Digest: image, Size: 1}
+
+			sbom, err := cli.PushReferrer(ctx, "library/app", subject, sbomType, []ArtifactBlob{
+				{MediaType: sbomType, Content: []byte(`{"spdxVersion":"SPDX-2.3"}`)},
+			}, map[string]string{"org.opencontainers.image.created": "2022-01-01T00:00:00Z"})
+			if err != nil {
+				t.Fatalf("PushReferrer() error = %v", err)
+			}
+			if _, err := cli.PushReferrer(ctx, "library/app", subject, attestationType, nil, nil); err != nil {
+				t.Fatalf("PushReferrer() error = %v", err)
+			}
+			_, hasTagIndex := registry.manifests["library/app"][ReferrersTag(image)]
+			if hasTagIndex == referrersAPI {
+				t.Errorf("referrers tag index exists = %v", hasTagIndex)
+			}
+
+			all, err := cli.ListReferrers(ctx, "library/app", image, "")
+			if err != nil {
+				t.Fatalf("ListReferrers() error = %v", err)
+			}
+			if len(all) != 2 {
+				t.Errorf("ListReferrers() = %+v", all)
+			}
+			sboms, err := cli.ListReferrers(ctx, "library/app", image, sbomType)
+			if err != nil {
+				t.Fatalf("ListReferrers() error = %v", err)
+			}
+			if len(sboms) != 1 || sboms[0].Digest != sbom.Digest || sboms[0].ArtifactType != sbomType {
+				t.Errorf("ListReferrers(%s) = %+v", sbomType, sboms)
+			}
+			if sboms[0].Annotations["org.opencontainers.image.created"] == "" {
+				t.Errorf("ListReferrers(%s) annotations = %v", sbomType, sboms[0].Annotations)
+			}
+
+			other := pushTestImage(registry, "library/app", "v2")
+			if none, err := cli.ListReferrers(ctx, "library/app", other, ""); err != nil || len(none) != 0 {
+				t.Errorf("ListReferrers() = %+v, %v", none, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,328 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	distributionspecsv1 "github.com/opencontainers/distribution-spec/specs-go/v1"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	imagespecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+type fakeManifest struct {
+	mediaType string
+	content   []byte
+}
+
+// fakeRegistry is an in-memory OCI distribution registry stand-in for tests
+type fakeRegistry struct {
+	mu        sync.Mutex
+	manifests map[string]map[string]fakeManifest  // repository -> tag or digest -> manifest
+	blobs     map[string]map[digest.Digest][]byte // repository -> digest -> content
+	uploads   map[string]*fakeUpload              // upload id -> upload session
+	// failPatchAt fails the PATCH request uploads data beyond the offset,zero means never fail
+	failPatchAt int64
+	// redirectBlobs redirects blob downloads to the storage url if set
+	redirectBlobs string
+	// referrers enables the referrers api
+	referrers bool
+	// disableMount starts an upload session instead of mounting
+	disableMount bool
+	// chunkedBlobs downloads blobs without Content-Length
+	chunkedBlobs bool
+	// truncateBlobs aborts the blob download after the size of bytes sent if set
+	truncateBlobs int
+}
+
+type fakeUpload struct {
+	repository string
+	data       []byte
+}
+
+var (
+	fakeRegistryRoute       = regexp.MustCompile(`^/v2/(.+)/(manifests|blobs|referrers)/([^/]+)$`)
+	fakeRegistryUploadRoute = regexp.MustCompile(`^/v2/(.+)/blobs/uploads/([^/]*)$`)
+	fakeRegistryTagsRoute   = regexp.MustCompile(`^/v2/(.+)/tags/list$`)
+)
+
+func newFakeRegistry(t *testing.T) (*fakeRegistry, *OCIDistributionClient) {
+	registry := &fakeRegistry{
+		manifests: map[string]map[string]fakeManifest{},
+		blobs:     map[string]map[digest.Digest][]byte{},
+		uploads:   map[string]*fakeUpload{},
+	}
+	server := httptest.NewServer(registry)
+	t.Cleanup(server.Close)
+	cli, _ := NewOCIDistributionClient(server.URL, nil)
+	return registry, cli
+}
+
+// putBlob stores content in repository and returns its digest
+func (r *fakeRegistry) putBlob(repository string, content []byte) digest.Digest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	dgst := digest.FromBytes(content)
+	if r.blobs[repository] == nil {
+		r.blobs[repository] = map[digest.Digest][]byte{}
+	}
+	r.blobs[repository][dgst] = content
+	return dgst
+}
+
+// putManifest stores the json encoded manifest by its digest and the tags
+func (r *fakeRegistry) putManifest(repository, mediaType string, manifest interface{}, tags ...string) digest.Digest {
+	content, _ := json.Marshal(manifest)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	dgst := digest.FromBytes(content)
+	if r.manifests[repository] == nil {
+		r.manifests[repository] = map[string]fakeManifest{}
+	}
+	for _, ref := range append(tags, dgst.String()) {
+		r.manifests[repository][ref] = fakeManifest{mediaType: mediaType, content: content}
+	}
+	return dgst
+}
+
+func (r *fakeRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if matches := fakeRegistryUploadRoute.FindStringSubmatch(req.URL.Path); matches != nil {
+		r.serveUpload(w, req, matches[1], matches[2])
+		return
+	}
+	if req.URL.Path == "/v2/_catalog" {
+		repositories := []string{}
+		for repository := range r.manifests {
+			repositories = append(repositories, repository)
+		}
+		r.serveList(w, req, "repositories", repositories)
+		return
+	}
+	if matches := fakeRegistryTagsRoute.FindStringSubmatch(req.URL.Path); matches != nil {
+		if r.manifests[matches[1]] == nil {
+			writeRegistryError(w, http.StatusNotFound, "NAME_UNKNOWN")
+			return
+		}
+		tags := []string{}
+		for reference := range r.manifests[matches[1]] {
+			if _, err := digest.Parse(reference); err != nil {
+				tags = append(tags, reference)
+			}
+		}
+		r.serveList(w, req, "tags", tags)
+		return
+	}
+	matches := fakeRegistryRoute.FindStringSubmatch(req.URL.Path)
+	if matches == nil {
+		writeRegistryError(w, http.StatusNotFound, "NAME_UNKNOWN")
+		return
+	}
+	repository, kind, reference := matches[1], matches[2], matches[3]
+	switch {
+	case kind == "manifests" && (req.Method == http.MethodGet || req.Method == http.MethodHead):
+		manifest, ok := r.manifests[repository][reference]
+		// like distribution,a manifest is unknown to the client does not accept its media type
+		if accept := req.Header.Get("Accept"); !ok || accept != "" && !strings.Contains(accept, manifest.mediaType) {
+			writeRegistryError(w, http.StatusNotFound, "MANIFEST_UNKNOWN")
+			return
+		}
+		w.Header().Set("Content-Type", manifest.mediaType)
+		w.Header().Set("Docker-Content-Digest", digest.FromBytes(manifest.content).String())
+		http.ServeContent(w, req, "", time.Time{}, bytes.NewReader(manifest.content))
+	case kind == "referrers" && !r.referrers:
+		writeRegistryError(w, http.StatusNotFound, "NAME_UNKNOWN")
+	case kind == "referrers" && req.Method == http.MethodGet:
+		r.serveReferrers(w, req, repository, digest.Digest(reference))
+	case kind == "manifests" && req.Method == http.MethodPut:
+		content, _ := io.ReadAll(req.Body)
+		dgst := digest.FromBytes(content)
+		if digest.Digest(reference).Validate() == nil && digest.Digest(reference) != dgst {
+			writeRegistryError(w, http.StatusBadRequest, "DIGEST_INVALID")
+			return
+		}
+		if r.manifests[repository] == nil {
+			r.manifests[repository] = map[string]fakeManifest{}
+		}
+		manifest := fakeManifest{mediaType: req.Header.Get("Content-Type"), content: content}
+		r.manifests[repository][reference] = manifest
+		r.manifests[repository][dgst.String()] = manifest
+		if subject := (ReferrerManifest{}); r.referrers && json.Unmarshal(content, &subject) == nil && subject.Subject != nil {
+			w.Header().Set("OCI-Subject", subject.Subject.Digest.String())
+		}
+		w.Header().Set("Location", "/v2/"+repository+"/manifests/"+dgst.String())
+		w.Header().Set("Docker-Content-Digest", dgst.String())
+		w.WriteHeader(http.StatusCreated)
+	case kind == "blobs" && (req.Method == http.MethodGet || req.Method == http.MethodHead):
+		content, ok := r.blobs[repository][digest.Digest(reference)]
+		if !ok {
+			writeRegistryError(w, http.StatusNotFound, "BLOB_UNKNOWN")
+			return
+		}
+		if r.redirectBlobs != "" && req.Method == http.MethodGet {
+			http.Redirect(w, req, r.redirectBlobs+"/"+reference, http.StatusTemporaryRedirect)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Docker-Content-Digest", reference)
+		if r.truncateBlobs > 0 && req.Method == http.MethodGet && req.Header.Get("Range") == "" {
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			_, _ = w.Write(content[:r.truncateBlobs])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		if r.chunkedBlobs && req.Method == http.MethodGet && req.Header.Get("Range") == "" {
+			w.(http.Flusher).Flush()
+			_, _ = w.Write(content)
+			return
+		}
+		http.ServeContent(w, req, "", time.Time{}, bytes.NewReader(content))
+	default:
+		writeRegistryError(w, http.StatusMethodNotAllowed, "UNSUPPORTED")
+	}
+}
+
+func (r *fakeRegistry) serveUpload(w http.ResponseWriter, req *http.Request, repository, id string) {
+	if req.Method == http.MethodPost && id == "" {
+		mount, from := digest.Digest(req.URL.Query().Get("mount")), req.URL.Query().Get("from")
+		if content, ok := r.blobs[from][mount]; ok && !r.disableMount {
+			if r.blobs[repository] == nil {
+				r.blobs[repository] = map[digest.Digest][]byte{}
+			}
+			r.blobs[repository][mount] = content
+			w.Header().Set("Location", "/v2/"+repository+"/blobs/"+mount.String())
+			w.Header().Set("Docker-Content-Digest", mount.String())
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		id = strconv.Itoa(len(r.uploads) + 1)
+		r.uploads[id] = &fakeUpload{repository: repository}
+		w.Header().Set("Location", "/v2/"+repository+"/blobs/uploads/"+id+"?_state="+id)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	upload, ok := r.uploads[id]
+	if !ok || upload.repository != repository {
+		writeRegistryError(w, http.StatusNotFound, "BLOB_UPLOAD_UNKNOWN")
+		return
+	}
+	setStatus := func() {
+		w.Header().Set("Location", "/v2/"+repository+"/blobs/uploads/"+id+"?_state="+strconv.Itoa(len(upload.data)))
+		end := len(upload.data) - 1
+		if end < 0 {
+			end = 0
+		}
+		w.Header().Set("Range", fmt.Sprintf("0-%d", end))
+	}
+	switch req.Method {
+	case http.MethodGet:
+		setStatus()
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodPatch:
+		var start, end int64
+		if _, err := fmt.Sscanf(req.Header.Get("Content-Range"), "%d-%d", &start, &end); err != nil || start != int64(len(upload.data)) {
+			writeRegistryError(w, http.StatusRequestedRangeNotSatisfiable, "BLOB_UPLOAD_INVALID")
+			return
+		}
+		chunk, _ := io.ReadAll(req.Body)
+		if int64(len(chunk)) != end-start+1 {
+			writeRegistryError(w, http.StatusBadRequest, "SIZE_INVALID")
+			return
+		}
+		if r.failPatchAt > 0 && end >= r.failPatchAt {
+			writeRegistryError(w, http.StatusInternalServerError, "UNKNOWN")
+			return
+		}
+		upload.data = append(upload.data, chunk...)
+		setStatus()
+		w.WriteHeader(http.StatusAccepted)
+	case http.MethodPut:
+		last, _ := io.ReadAll(req.Body)
+		data := append(upload.data, last...)
+		dgst := digest.Digest(req.URL.Query().Get("digest"))
+		if digest.FromBytes(data) != dgst {
+			writeRegistryError(w, http.StatusBadRequest, "DIGEST_INVALID")
+			return
+		}
+		delete(r.uploads, id)
+		if r.blobs[repository] == nil {
+			r.blobs[repository] = map[digest.Digest][]byte{}
+		}
+		r.blobs[repository][dgst] = data
+		w.Header().Set("Location", "/v2/"+repository+"/blobs/"+dgst.String())
+		w.Header().Set("Docker-Content-Digest", dgst.String())
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodDelete:
+		delete(r.uploads, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeRegistryError(w, http.StatusMethodNotAllowed, "UNSUPPORTED")
+	}
+}
+
+func (r *fakeRegistry) serveReferrers(w http.ResponseWriter, req *http.Request, repository string, subject digest.Digest) {
+	index := ReferrerIndex{Versioned: specs.Versioned{SchemaVersion: 2}, MediaType: imagespecv1.MediaTypeImageIndex, Manifests: []ReferrerDescriptor{}}
+	artifactType := req.URL.Query().Get("artifactType")
+	for reference, manifest := range r.manifests[repository] {
+		dgst := digest.FromBytes(manifest.content)
+		referrer := ReferrerManifest{}
+		if reference != dgst.String() || json.Unmarshal(manifest.content, &referrer) != nil {
+			continue
+		}
+		if referrer.Subject == nil || referrer.Subject.Digest != subject {
+			continue
+		}
+		if artifactType != "" && referrer.ArtifactType != artifactType {
+			continue
+		}
+		descriptor := ReferrerDescriptor{ArtifactType: referrer.ArtifactType}
+		descriptor.MediaType, descriptor.Digest, descriptor.Size = manifest.mediaType, dgst, int64(len(manifest.content))
+		descriptor.Annotations = referrer.Annotations
+		index.Manifests = append(index.Manifests, descriptor)
+	}
+	sort.Slice(index.Manifests, func(i, j int) bool { return index.Manifests[i].Digest < index.Manifests[j].Digest })
+	if artifactType != "" {
+		w.Header().Set("OCI-Filters-Applied", "artifactType")
+	}
+	w.Header().Set("Content-Type", imagespecv1.MediaTypeImageIndex)
+	_ = json.NewEncoder(w).Encode(index)
+}
+
+// serveList serves a page of sorted items after 'last' with the Link of next page
+func (r *fakeRegistry) serveList(w http.ResponseWriter, req *http.Request, key string, items []string) {
+	sort.Strings(items)
+	query := req.URL.Query()
+	if last := query.Get("last"); last != "" {
+		items = items[sort.SearchStrings(items, last):]
+		if len(items) > 0 && items[0] == last {
+			items = items[1:]
+		}
+	}
+	if n, _ := strconv.Atoi(query.Get("n")); n > 0 && len(items) > n {
+		items = items[:n]
+		next := url.Values{"n": []string{query.Get("n")}, "last": []string{items[n-1]}}
+		w.Header().Set("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, req.URL.Path, next.Encode()))
+	}
+	_ = json.NewEncoder(w).Encode(map[string][]string{key: items})
+}
+
+func writeRegistryError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(distributionspecsv1.ErrorResponse{
+		Errors: []distributionspecsv1.ErrorInfo{{Code: code, Message: http.StatusText(status)}},
+	})
+}
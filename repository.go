@@ -35,13 +35,13 @@ type RepositoryList struct {
 // ListRepositories
 // GET /projects/{project_name}/repositories
 func (c *Client) ListRepositories(ctx context.Context, project string, options RepositoriesListOptions) (RepositoryList, error) {
-	path := fmt.Sprintf("/projects/%s/repositories", project)
+	path := fmt.Sprintf("/projects/%s/repositories?%s", project, options.toQuery().Encode())
 	ret := RepositoryList{}
 	resp, err := c.doRequestWithResponse(ctx, http.MethodGet, path, nil, &ret)
 	if err != nil {
 		return ret, err
 	}
-	if total, err := strconv.Atoi(resp.Header.Get(xTotalCountHeader)); err != nil {
+	if total, err := strconv.Atoi(resp.Header.Get(xTotalCountHeader)); err == nil {
 		ret.Total = total
 	}
 	// Link: </api/v2.0/projects/library/repositories?page=2&page_size=10>; rel="next"
@@ -0,0 +1,137 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var ErrSBOMNotFound = errors.New("sbom not generated")
+
+// ScanType is the type of scan request
+type ScanType string
+
+const (
+	ScanTypeVulnerability ScanType = "vulnerability"
+	ScanTypeSBOM          ScanType = "sbom"
+)
+
+// ScanRequest same as harbor swagger 'ScanType'
+type ScanRequest struct {
+	ScanType ScanType `json:"scan_type,omitempty"`
+}
+
+// SBOMOverview same as harbor swagger 'SBOMOverview'
+type SBOMOverview struct {
+	StartTime       time.Time    `json:"start_time"`
+	EndTime         time.Time    `json:"end_time"`
+	ScanStatus      string       `json:"scan_status"`
+	SBOMDigest      string       `json:"sbom_digest"` // digest of the sbom accessory
+	ReportID        string       `json:"report_id"`
+	Duration        int64        `json:"duration"` // seconds
+	Scanner         *ScannerInfo `json:"scanner,omitempty"`
+	CompletePercent int          `json:"complete_percent"`
+}
+
+// SBOMFormat is the format of sbom document
+type SBOMFormat string
+
+const (
+	SBOMFormatSPDX      SBOMFormat = "spdx"
+	SBOMFormatCycloneDX SBOMFormat = "cyclonedx"
+	SBOMFormatUnknown   SBOMFormat = ""
+)
+
+type SBOM struct {
+	Digest  string // digest of the sbom accessory
+	Format  SBOMFormat
+	Content []byte // the raw document
+}
+
+// GenerateSBOM requests the default scanner of the project to generate sbom of the artifact.
+// The sbom is stored as an accessory of the artifact.
+// POST /projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/scan
+func (c *Client) GenerateSBOM(ctx context.Context, project, repository, reference string) error {
+	path := fmt.Sprintf("/projects/%s/repositories/%s/artifacts/%s/scan", project, repository, reference)
+	return c.doRequest(ctx, http.MethodPost, path, ScanRequest{ScanType: ScanTypeSBOM}, nil)
+}
+
+// StopSBOM stops the sbom generation of the artifact
+// POST /projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/scan/stop
+func (c *Client) StopSBOM(ctx context.Context, project, repository, reference string) error {
+	path := fmt.Sprintf("/projects/%s/repositories/%s/artifacts/%s/scan/stop", project, repository, reference)
+	return c.doRequest(ctx, http.MethodPost, path, ScanRequest{ScanType: ScanTypeSBOM}, nil)
+}
+
+// GetSBOMOverview returns the sbom overview of the artifact,nil if no sbom generated.
+func (c *Client) GetSBOMOverview(ctx context.Context, project, repository, reference string) (*SBOMOverview, error) {
+	artifact, err := c.GetArtifact(ctx, project, repository, reference, GetArtifactOptions{WithSBOMOverview: true})
+	if err != nil {
+		return nil, err
+	}
+	return artifact.SBOMOverview, nil
+}
+
+// WaitForSBOM triggers the sbom generation of the artifact and polls its sbom overview with backoff until finished.
+// when the generation is Error or Stopped a *ScanError contains the report log is returned with the overview.
+func (c *Client) WaitForSBOM(ctx context.Context, project, repository, reference string, options ScanOptions) (*SBOMOverview, error) {
+	var overview *SBOMOverview
+	err := c.waitScanJob(ctx, project, repository, reference, options, scanJob{
+		latest: func(ctx context.Context) (string, string, error) {
+			current, err := c.GetSBOMOverview(ctx, project, repository, reference)
+			if err != nil || current == nil {
+				return "", "", err
+			}
+			overview = current
+			return current.ReportID, current.ScanStatus, nil
+		},
+		trigger: func(ctx context.Context) error { return c.GenerateSBOM(ctx, project, repository, reference) },
+		stop:    func(ctx context.Context) error { return c.StopSBOM(ctx, project, repository, reference) },
+	})
+	if scanerr := (&ScanError{}); err != nil && !errors.As(err, &scanerr) {
+		return nil, err
+	}
+	return overview, err
+}
+
+// GetSBOM returns the latest generated sbom document of the artifact.
+// The sbom accessory digest is discovered via the artifact's sbom_overview,
+// ErrSBOMNotFound is returned if no sbom generated successfully.
+// GET /projects/{project_name}/repositories/{repository_name}/artifacts/{sbom_digest}/additions/sbom
+func (c *Client) GetSBOM(ctx context.Context, project, repository, reference string) (*SBOM, error) {
+	overview, err := c.GetSBOMOverview(ctx, project, repository, reference)
+	if err != nil {
+		return nil, err
+	}
+	if overview == nil || overview.SBOMDigest == "" || overview.ScanStatus != JobStatusSuccess {
+		return nil, ErrSBOMNotFound
+	}
+	content, err := c.GetArtifactadditions(ctx, project, repository, overview.SBOMDigest, AdditionSBOM)
+	if err != nil {
+		return nil, err
+	}
+	return &SBOM{Digest: overview.SBOMDigest, Format: DetectSBOMFormat(content), Content: content}, nil
+}
+
+// DetectSBOMFormat detects the format of a json sbom document
+func DetectSBOMFormat(content []byte) SBOMFormat {
+	header := struct {
+		SPDXVersion string `json:"spdxVersion"`
+		BOMFormat   string `json:"bomFormat"`
+	}{}
+	if err := json.NewDecoder(bytes.NewReader(content)).Decode(&header); err != nil {
+		return SBOMFormatUnknown
+	}
+	switch {
+	case header.SPDXVersion != "":
+		return SBOMFormatSPDX
+	case header.BOMFormat == "CycloneDX":
+		return SBOMFormatCycloneDX
+	default:
+		return SBOMFormatUnknown
+	}
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testSBOMDigest = "sha256:5d0da3dc976460b72c77d94c8a1ad043720b0416bfc16c52c45d4847e53fadb6"
+
+// fakeSBOMServer generates sbom of an artifact in 'polls' polls
+type fakeSBOMServer struct {
+	mu        sync.Mutex
+	polls     int
+	overview  *SBOMOverview
+	generated bool
+	scanType  ScanType
+}
+
+func (s *fakeSBOMServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	const repositoryPath = "/api/v2.0/projects/library/repositories/nginx/artifacts/"
+	switch {
+	case r.URL.Path == "/api/v2.0/systeminfo":
+		w.Header().Set(csrfTokenHeader, "token")
+		_, _ = w.Write([]byte(`{}`))
+	case r.Method == http.MethodPost && r.URL.Path == repositoryPath+"latest/scan":
+		req := ScanRequest{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		s.scanType, s.generated = req.ScanType, true
+		s.overview = &SBOMOverview{ReportID: "sbom-report", ScanStatus: JobStatusRunning}
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == http.MethodGet && r.URL.Path == repositoryPath+"latest":
+		if r.URL.Query().Get("with_sbom_overview") != "true" {
+			http.Error(w, "sbom overview not requested", http.StatusBadRequest)
+			return
+		}
+		if s.generated {
+			if s.polls--; s.polls <= 0 {
+				s.overview.ScanStatus, s.overview.SBOMDigest = JobStatusSuccess, testSBOMDigest
+			}
+		}
+		_ = json.NewEncoder(w).Encode(Artifact{SBOMOverview: s.overview})
+	case r.Method == http.MethodGet && r.URL.Path == repositoryPath+testSBOMDigest+"/additions/sbom":
+		_, _ = w.Write([]byte(`{"spdxVersion":"SPDX-2.3","name":"library/nginx"}`))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestClient_WaitForSBOM(t *testing.T) {
+	fake := &fakeSBOMServer{polls: 3}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	cli, _ := NewClient(server.URL)
+	ctx := context.Background()
+
+	if _, err := cli.GetSBOM(ctx, "library", "nginx", "latest"); !errors.Is(err, ErrSBOMNotFound) {
+		t.Fatalf("GetSBOM() error = %v, want ErrSBOMNotFound", err)
+	}
+
+	options := ScanOptions{Interval: time.Millisecond, MaxInterval: 5 * time.Millisecond}
+	overview, err := cli.WaitForSBOM(ctx, "library", "nginx", "latest", options)
+	if err != nil {
+		t.Fatalf("WaitForSBOM() error = %v", err)
+	}
+	if fake.scanType != ScanTypeSBOM {
+		t.Errorf("scan_type = %q, want %q", fake.scanType, ScanTypeSBOM)
+	}
+	if overview.ScanStatus != JobStatusSuccess || overview.SBOMDigest != testSBOMDigest {
+		t.Errorf("WaitForSBOM() = %+v", overview)
+	}
+
+	sbom, err := cli.GetSBOM(ctx, "library", "nginx", "latest")
+	if err != nil {
+		t.Fatalf("GetSBOM() error = %v", err)
+	}
+	if sbom.Digest != testSBOMDigest || sbom.Format != SBOMFormatSPDX || len(sbom.Content) == 0 {
+		t.Errorf("GetSBOM() = %+v", sbom)
+	}
+}
+
+func TestDetectSBOMFormat(t *testing.T) {
+	tests := []struct {
+		content string
+		want    SBOMFormat
+	}{
+		{`{"spdxVersion":"SPDX-2.3"}`, SBOMFormatSPDX},
+		{`{"bomFormat":"CycloneDX","specVersion":"1.5"}`, SBOMFormatCycloneDX},
+		{`{"name":"unknown"}`, SBOMFormatUnknown},
+		{`not json`, SBOMFormatUnknown},
+	}
+	for _, tt := range tests {
+		if got := DetectSBOMFormat([]byte(tt.content)); got != tt.want {
+			t.Errorf("DetectSBOMFormat(%s) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
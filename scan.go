@@ -2,13 +2,26 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/goharbor/harbor/src/pkg/scan/vuln"
 )
 
 // GET /projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/scan/{report_id}/log
+//
+// Deprecated: harbor report id is an uuid,use GetScanLog instead.
 func (c *Client) GetScanReportLog(ctx context.Context, project, repository, reference string, reportID int) ([]byte, error) {
-	path := fmt.Sprintf("/projects/%s/repositories/%s/artifacts/%s/scan/%d/log", project, repository, reference, reportID)
+	return c.GetScanLog(ctx, project, repository, reference, strconv.Itoa(reportID))
+}
+
+// GetScanLog gets the log of the scan report,reportID is the 'report_id' in scan overview.
+// GET /projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/scan/{report_id}/log
+func (c *Client) GetScanLog(ctx context.Context, project, repository, reference string, reportID string) ([]byte, error) {
+	path := fmt.Sprintf("/projects/%s/repositories/%s/artifacts/%s/scan/%s/log", project, repository, reference, reportID)
 	log := []byte{}
 	err := c.doRequest(ctx, http.MethodGet, path, nil, &log)
 	return log, err
@@ -19,3 +32,134 @@ func (c *Client) ScanArtifact(ctx context.Context, project, repository, referenc
 	path := fmt.Sprintf("/projects/%s/repositories/%s/artifacts/%s/scan", project, repository, reference)
 	return c.doRequest(ctx, http.MethodPost, path, nil, nil)
 }
+
+// POST /projects/{project_name}/repositories/{repository_name}/artifacts/{reference}/scan/stop
+func (c *Client) StopScan(ctx context.Context, project, repository, reference string) error {
+	path := fmt.Sprintf("/projects/%s/repositories/%s/artifacts/%s/scan/stop", project, repository, reference)
+	return c.doRequest(ctx, http.MethodPost, path, nil, nil)
+}
+
+type ScanOptions struct {
+	// Interval is the initial interval to poll the scan status,default 2s.
+	// It doubles after each poll until MaxInterval.
+	Interval time.Duration
+	// MaxInterval default 30s.
+	MaxInterval time.Duration
+	// StopOnCancel stops the scan when ctx canceled or deadline exceeded.
+	StopOnCancel bool
+}
+
+type ScanResult struct {
+	ReportID string
+	MimeType string // the mime type of report,e.g. "application/vnd.security.vulnerability.report; version=1.1"
+	Summary  vuln.NativeReportSummary
+}
+
+// ScanError is returned by ScanAndWait when the scan finished with status Error or Stopped
+type ScanError struct {
+	Status   string
+	ReportID string
+	Log      []byte // the scan report log,may be empty
+}
+
+func (e *ScanError) Error() string {
+	if len(e.Log) == 0 {
+		return fmt.Sprintf("scan %s: %s", e.ReportID, e.Status)
+	}
+	return fmt.Sprintf("scan %s: %s: %s", e.ReportID, e.Status, e.Log)
+}
+
+// ScanAndWait triggers a scan of the artifact and polls its scan overview with backoff until finished.
+// It returns the final summary and report id,
+// when the scan is Error or Stopped a *ScanError contains the scan report log is returned with the result.
+func (c *Client) ScanAndWait(ctx context.Context, project, repository, reference string, options ScanOptions) (*ScanResult, error) {
+	result := &ScanResult{}
+	err := c.waitScanJob(ctx, project, repository, reference, options, scanJob{
+		latest: func(ctx context.Context) (string, string, error) {
+			artifact, err := c.GetArtifact(ctx, project, repository, reference, GetArtifactOptions{WithScanOverview: true})
+			if err != nil {
+				return "", "", err
+			}
+			mimetype, summary, ok := latestScanSummary(artifact.ScanOverview)
+			if !ok {
+				return "", "", nil
+			}
+			result.ReportID, result.MimeType, result.Summary = summary.ReportID, mimetype, summary
+			return summary.ReportID, summary.ScanStatus, nil
+		},
+		trigger: func(ctx context.Context) error { return c.ScanArtifact(ctx, project, repository, reference) },
+		stop:    func(ctx context.Context) error { return c.StopScan(ctx, project, repository, reference) },
+	})
+	if scanerr := (&ScanError{}); err != nil && !errors.As(err, &scanerr) {
+		return nil, err
+	}
+	return result, err
+}
+
+// scanJob is a kind of scan job of an artifact,e.g. vulnerability scan or sbom generation
+type scanJob struct {
+	// latest returns the report id and status of the latest job,empty report id if none
+	latest  func(ctx context.Context) (reportID, status string, err error)
+	trigger func(ctx context.Context) error
+	stop    func(ctx context.Context) error
+}
+
+// waitScanJob triggers the job and polls it with backoff until a new report finished.
+// when the job is Error or Stopped a *ScanError contains the report log is returned.
+func (c *Client) waitScanJob(ctx context.Context, project, repository, reference string, options ScanOptions, job scanJob) error {
+	if options.Interval <= 0 {
+		options.Interval = 2 * time.Second
+	}
+	if options.MaxInterval < options.Interval {
+		options.MaxInterval = 30 * time.Second
+	}
+	// reports of previous jobs are replaced by a new report id
+	previous, _, err := job.latest(ctx)
+	if err != nil {
+		return err
+	}
+	if err := job.trigger(ctx); err != nil {
+		return err
+	}
+
+	reportID, status := "", ""
+	err = poll(ctx, options.Interval, options.MaxInterval, func(ctx context.Context) (bool, error) {
+		id, st, err := job.latest(ctx)
+		if err != nil {
+			return false, err
+		}
+		if id == "" || id == previous {
+			return false, nil
+		}
+		reportID, status = id, st
+		return IsJobFinished(st), nil
+	})
+	if err != nil {
+		if options.StopOnCancel && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+			stopctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			_ = job.stop(stopctx)
+		}
+		return err
+	}
+
+	if status == JobStatusSuccess {
+		return nil
+	}
+	scanerr := &ScanError{Status: status, ReportID: reportID}
+	if log, err := c.GetScanLog(ctx, project, repository, reference, reportID); err == nil {
+		scanerr.Log = log
+	}
+	return scanerr
+}
+
+// latestScanSummary returns the summary started lastest in scan overview
+func latestScanSummary(overview map[string]vuln.NativeReportSummary) (string, vuln.NativeReportSummary, bool) {
+	mimetype, latest, found := "", vuln.NativeReportSummary{}, false
+	for k, summary := range overview {
+		if !found || summary.StartTime.After(latest.StartTime) {
+			mimetype, latest, found = k, summary, true
+		}
+	}
+	return mimetype, latest, found
+}
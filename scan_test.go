@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goharbor/harbor/src/pkg/scan/vuln"
+)
+
+const testReportMimeType = "application/vnd.security.vulnerability.report; version=1.1"
+
+// fakeScanServer scans an artifact in 'polls' polls and finishes with 'status'
+type fakeScanServer struct {
+	mu       sync.Mutex
+	polls    int
+	status   string
+	scanned  bool
+	overview map[string]vuln.NativeReportSummary
+	stopped  bool
+}
+
+func (s *fakeScanServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	const artifactPath = "/api/v2.0/projects/library/repositories/nginx/artifacts/latest"
+	switch {
+	case r.URL.Path == "/api/v2.0/systeminfo":
+		w.Header().Set(csrfTokenHeader, "token")
+		_, _ = w.Write([]byte(`{}`))
+	case r.Method == http.MethodPost && r.URL.Path == artifactPath+"/scan":
+		s.scanned = true
+		s.overview = map[string]vuln.NativeReportSummary{
+			testReportMimeType: {ReportID: "new-report", ScanStatus: JobStatusPending, StartTime: time.Now()},
+		}
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == http.MethodPost && r.URL.Path == artifactPath+"/scan/stop":
+		s.stopped = true
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == http.MethodGet && r.URL.Path == artifactPath:
+		if s.scanned {
+			if s.polls--; s.polls <= 0 {
+				summary := s.overview[testReportMimeType]
+				summary.ScanStatus = s.status
+				s.overview[testReportMimeType] = summary
+			}
+		}
+		_ = json.NewEncoder(w).Encode(Artifact{ScanOverview: s.overview})
+	case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/scan/new-report/log"):
+		_, _ = w.Write([]byte("scanner adapter unavailable"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestClient_ScanAndWait(t *testing.T) {
+	previous := map[string]vuln.NativeReportSummary{
+		testReportMimeType: {ReportID: "old-report", ScanStatus: JobStatusSuccess},
+	}
+	options := ScanOptions{Interval: time.Millisecond, MaxInterval: 5 * time.Millisecond}
+
+	fake := &fakeScanServer{polls: 3, status: JobStatusSuccess, overview: previous}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	cli, _ := NewClient(server.URL)
+
+	result, err := cli.ScanAndWait(context.Background(), "library", "nginx", "latest", options)
+	if err != nil {
+		t.Fatalf("ScanAndWait() error = %v", err)
+	}
+	if result.ReportID != "new-report" || result.MimeType != testReportMimeType || result.Summary.ScanStatus != JobStatusSuccess {
+		t.Errorf("ScanAndWait() = %+v", result)
+	}
+
+	failed := &fakeScanServer{polls: 2, status: JobStatusError}
+	failedserver := httptest.NewServer(failed)
+	defer failedserver.Close()
+	cli, _ = NewClient(failedserver.URL)
+
+	_, err = cli.ScanAndWait(context.Background(), "library", "nginx", "latest", options)
+	scanerr := &ScanError{}
+	if !errors.As(err, &scanerr) {
+		t.Fatalf("ScanAndWait() error = %v, want *ScanError", err)
+	}
+	if scanerr.Status != JobStatusError || string(scanerr.Log) != "scanner adapter unavailable" {
+		t.Errorf("ScanAndWait() error = %+v", scanerr)
+	}
+}
+
+func TestClient_ScanAndWait_StopOnCancel(t *testing.T) {
+	fake := &fakeScanServer{polls: 1 << 30, status: JobStatusSuccess}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	cli, _ := NewClient(server.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	options := ScanOptions{Interval: time.Millisecond, MaxInterval: 5 * time.Millisecond, StopOnCancel: true}
+	if _, err := cli.ScanAndWait(ctx, "library", "nginx", "latest", options); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ScanAndWait() error = %v", err)
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if !fake.stopped {
+		t.Error("scan not stopped after ctx canceled")
+	}
+}
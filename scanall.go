@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// ScanAllMetrics same as harbor swagger 'Stats'
+type ScanAllMetrics struct {
+	Total     int            `json:"total"`     // the total number of scan processes triggered
+	Completed int            `json:"completed"` // the number of the finished scan processes
+	Metrics   map[string]int `json:"metrics"`   // status as key,count as value,e.g. {"Success":5,"Error":1,"Running":2}
+	Requester string         `json:"requester"` // execution id of the scan all job
+	Ongoing   bool           `json:"ongoing"`
+	Trigger   string         `json:"trigger"` // "Manual","Schedule" or "Event"
+}
+
+// Percent returns the completed percentage of scan all
+func (m ScanAllMetrics) Percent() float64 {
+	if m.Total == 0 {
+		return 0
+	}
+	return float64(m.Completed) * 100 / float64(m.Total)
+}
+
+// GET /system/scanAll/schedule
+func (c *Client) GetScanAllSchedule(ctx context.Context) (Schedule, error) {
+	ret := Schedule{}
+	if err := c.doRequest(ctx, http.MethodGet, "/system/scanAll/schedule", nil, &ret); err != nil {
+		return ret, err
+	}
+	return ret, nil
+}
+
+// POST /system/scanAll/schedule
+func (c *Client) CreateScanAllSchedule(ctx context.Context, schedule ScheduleObj) error {
+	return c.doRequest(ctx, http.MethodPost, "/system/scanAll/schedule", Schedule{Schedule: &schedule}, nil)
+}
+
+// PUT /system/scanAll/schedule
+// Use ScheduleTypeNone to remove the schedule.
+func (c *Client) UpdateScanAllSchedule(ctx context.Context, schedule ScheduleObj) error {
+	return c.doRequest(ctx, http.MethodPut, "/system/scanAll/schedule", Schedule{Schedule: &schedule}, nil)
+}
+
+// TriggerScanAll scan all artifacts immediately
+// POST /system/scanAll/schedule
+func (c *Client) TriggerScanAll(ctx context.Context) error {
+	return c.CreateScanAllSchedule(ctx, ScheduleObj{Type: ScheduleTypeManual})
+}
+
+// POST /system/scanAll/stop
+func (c *Client) StopScanAll(ctx context.Context) error {
+	return c.doRequest(ctx, http.MethodPost, "/system/scanAll/stop", nil, nil)
+}
+
+// GetScanAllMetrics returns the metrics of the latest scan all
+// GET /scans/all/metrics
+func (c *Client) GetScanAllMetrics(ctx context.Context) (ScanAllMetrics, error) {
+	ret := ScanAllMetrics{}
+	if err := c.doRequest(ctx, http.MethodGet, "/scans/all/metrics", nil, &ret); err != nil {
+		return ret, err
+	}
+	return ret, nil
+}
+
+// GetScheduledScanAllMetrics returns the metrics of the latest scheduled scan all
+// GET /scans/schedule/metrics
+func (c *Client) GetScheduledScanAllMetrics(ctx context.Context) (ScanAllMetrics, error) {
+	ret := ScanAllMetrics{}
+	if err := c.doRequest(ctx, http.MethodGet, "/scans/schedule/metrics", nil, &ret); err != nil {
+		return ret, err
+	}
+	return ret, nil
+}
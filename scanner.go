@@ -0,0 +1,191 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"time"
+)
+
+// ScannerAuth is the authentication approach harbor used to call the scanner adapter
+type ScannerAuth string
+
+const (
+	ScannerAuthNone   ScannerAuth = ""
+	ScannerAuthBasic  ScannerAuth = "Basic"                    // credential in format "username:password"
+	ScannerAuthBearer ScannerAuth = "Bearer"                   // credential is the token
+	ScannerAuthAPIKey ScannerAuth = "X-ScannerAdapter-API-Key" // credential is the api key
+)
+
+// ScannerRegistration same as harbor swagger 'ScannerRegistration'
+type ScannerRegistration struct {
+	UUID             string                 `json:"uuid"`
+	Name             string                 `json:"name"`
+	Description      string                 `json:"description"`
+	URL              string                 `json:"url"`
+	Disabled         bool                   `json:"disabled"`
+	IsDefault        bool                   `json:"is_default"`
+	Auth             ScannerAuth            `json:"auth"`
+	AccessCredential string                 `json:"access_credential"`
+	SkipCertVerify   bool                   `json:"skip_certVerify"`
+	UseInternalAddr  bool                   `json:"use_internal_addr"`
+	CreateTime       time.Time              `json:"create_time"`
+	UpdateTime       time.Time              `json:"update_time"`
+	Adapter          string                 `json:"adapter"`
+	Vendor           string                 `json:"vendor"`
+	Version          string                 `json:"version"`
+	Health           string                 `json:"health"`
+	Capabilities     map[string]interface{} `json:"capabilities,omitempty"`
+}
+
+// ScannerRegistrationRequest same as harbor swagger 'ScannerRegistrationReq'
+type ScannerRegistrationRequest struct {
+	Name             string      `json:"name"`
+	Description      string      `json:"description,omitempty"`
+	URL              string      `json:"url"`
+	Auth             ScannerAuth `json:"auth,omitempty"`
+	AccessCredential string      `json:"access_credential,omitempty"`
+	SkipCertVerify   bool        `json:"skip_certVerify"`
+	UseInternalAddr  bool        `json:"use_internal_addr"`
+	Disabled         bool        `json:"disabled"`
+}
+
+// ScannerCapabilityType is the type of scan a scanner capability supports
+type ScannerCapabilityType string
+
+const (
+	ScannerCapabilityVulnerability ScannerCapabilityType = "vulnerability"
+	ScannerCapabilitySBOM          ScannerCapabilityType = "sbom"
+)
+
+// ScannerCapability same as harbor swagger 'ScannerCapability'
+type ScannerCapability struct {
+	// Type is empty in older scanners which only support vulnerability
+	Type              ScannerCapabilityType `json:"type,omitempty"`
+	ConsumesMimeTypes []string              `json:"consumes_mime_types"`
+	ProducesMimeTypes []string              `json:"produces_mime_types"`
+}
+
+// ScannerInfo same as harbor swagger 'Scanner'
+type ScannerInfo struct {
+	Name    string `json:"name"`
+	Vendor  string `json:"vendor"`
+	Version string `json:"version"`
+}
+
+// ScannerAdapterMetadata same as harbor swagger 'ScannerAdapterMetadata'
+type ScannerAdapterMetadata struct {
+	Scanner      ScannerInfo         `json:"scanner"`
+	Capabilities []ScannerCapability `json:"capabilities"`
+	Properties   map[string]string   `json:"properties"`
+}
+
+// Supports reports whether the scanner produces the report of the capability type
+func (m ScannerAdapterMetadata) Supports(capability ScannerCapabilityType) bool {
+	for _, c := range m.Capabilities {
+		if c.Type == capability || (c.Type == "" && capability == ScannerCapabilityVulnerability) {
+			return true
+		}
+	}
+	return false
+}
+
+// GET /scanners
+func (c *Client) ListScanners(ctx context.Context, options CommonListOptions) ([]ScannerRegistration, error) {
+	path := fmt.Sprintf("/scanners?%s", options.toQuery().Encode())
+	ret := []ScannerRegistration{}
+	if err := c.doRequest(ctx, http.MethodGet, path, nil, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// RegisterScanner returns the uuid of the registered scanner
+// POST /scanners
+func (c *Client) RegisterScanner(ctx context.Context, scanner ScannerRegistrationRequest) (string, error) {
+	resp, err := c.doRequestWithResponse(ctx, http.MethodPost, "/scanners", scanner, nil)
+	if err != nil {
+		return "", err
+	}
+	// Location: /api/v2.0/scanners/{registration_id}
+	return path.Base(resp.Header.Get("Location")), nil
+}
+
+// GET /scanners/{registration_id}
+func (c *Client) GetScanner(ctx context.Context, uuid string) (ScannerRegistration, error) {
+	path := fmt.Sprintf("/scanners/%s", uuid)
+	ret := ScannerRegistration{}
+	if err := c.doRequest(ctx, http.MethodGet, path, nil, &ret); err != nil {
+		return ret, err
+	}
+	return ret, nil
+}
+
+// PUT /scanners/{registration_id}
+func (c *Client) UpdateScanner(ctx context.Context, uuid string, scanner ScannerRegistrationRequest) error {
+	path := fmt.Sprintf("/scanners/%s", uuid)
+	return c.doRequest(ctx, http.MethodPut, path, scanner, nil)
+}
+
+// DELETE /scanners/{registration_id}
+func (c *Client) DeleteScanner(ctx context.Context, uuid string) error {
+	path := fmt.Sprintf("/scanners/%s", uuid)
+	return c.doRequest(ctx, http.MethodDelete, path, nil, nil)
+}
+
+// PATCH /scanners/{registration_id}
+// body: {"is_default":true}
+func (c *Client) SetDefaultScanner(ctx context.Context, uuid string) error {
+	path := fmt.Sprintf("/scanners/%s", uuid)
+	return c.doRequest(ctx, http.MethodPatch, path, map[string]bool{"is_default": true}, nil)
+}
+
+// PingScanner tests the connection of scanner adapter with the settings
+// POST /scanners/ping
+func (c *Client) PingScanner(ctx context.Context, scanner ScannerRegistrationRequest) error {
+	settings := ScannerRegistrationRequest{
+		Name:             scanner.Name,
+		URL:              scanner.URL,
+		Auth:             scanner.Auth,
+		AccessCredential: scanner.AccessCredential,
+	}
+	return c.doRequest(ctx, http.MethodPost, "/scanners/ping", settings, nil)
+}
+
+// GET /scanners/{registration_id}/metadata
+func (c *Client) GetScannerMetadata(ctx context.Context, uuid string) (ScannerAdapterMetadata, error) {
+	path := fmt.Sprintf("/scanners/%s/metadata", uuid)
+	ret := ScannerAdapterMetadata{}
+	if err := c.doRequest(ctx, http.MethodGet, path, nil, &ret); err != nil {
+		return ret, err
+	}
+	return ret, nil
+}
+
+// GET /projects/{project_name_or_id}/scanner
+func (c *Client) GetProjectScanner(ctx context.Context, project string) (ScannerRegistration, error) {
+	path := fmt.Sprintf("/projects/%s/scanner", project)
+	ret := ScannerRegistration{}
+	if err := c.doRequest(ctx, http.MethodGet, path, nil, &ret); err != nil {
+		return ret, err
+	}
+	return ret, nil
+}
+
+// PUT /projects/{project_name_or_id}/scanner
+// body: {"uuid":"..."}
+func (c *Client) SetProjectScanner(ctx context.Context, project string, uuid string) error {
+	path := fmt.Sprintf("/projects/%s/scanner", project)
+	return c.doRequest(ctx, http.MethodPut, path, map[string]string{"uuid": uuid}, nil)
+}
+
+// GET /projects/{project_name_or_id}/scanner/candidates
+func (c *Client) ListProjectScannerCandidates(ctx context.Context, project string, options CommonListOptions) ([]ScannerRegistration, error) {
+	path := fmt.Sprintf("/projects/%s/scanner/candidates?%s", project, options.toQuery().Encode())
+	ret := []ScannerRegistration{}
+	if err := c.doRequest(ctx, http.MethodGet, path, nil, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ScheduleType is the type of a harbor schedule
+type ScheduleType string
+
+const (
+	ScheduleTypeHourly   ScheduleType = "Hourly"
+	ScheduleTypeDaily    ScheduleType = "Daily"
+	ScheduleTypeWeekly   ScheduleType = "Weekly"
+	ScheduleTypeCustom   ScheduleType = "Custom" // use ScheduleObj.Cron
+	ScheduleTypeManual   ScheduleType = "Manual" // trigger immediately
+	ScheduleTypeNone     ScheduleType = "None"   // remove the schedule
+	ScheduleTypeSchedule ScheduleType = "Schedule"
+)
+
+// ScheduleObj same as harbor swagger 'ScheduleObj'
+type ScheduleObj struct {
+	Type ScheduleType `json:"type,omitempty"`
+	// A cron expression, a time-based job scheduler.
+	// harbor use 6 fields cron expression(with seconds), e.g. "0 0 0 * * *"
+	Cron              string     `json:"cron,omitempty"`
+	NextScheduledTime *time.Time `json:"next_scheduled_time,omitempty"`
+}
+
+// Schedule same as harbor swagger 'Schedule'
+type Schedule struct {
+	ID           int64                  `json:"id,omitempty"`
+	Status       string                 `json:"status,omitempty"`
+	CreationTime *time.Time             `json:"creation_time,omitempty"`
+	UpdateTime   *time.Time             `json:"update_time,omitempty"`
+	Schedule     *ScheduleObj           `json:"schedule,omitempty"`
+	Parameters   map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// Job status of a harbor execution
+const (
+	JobStatusPending   = "Pending"
+	JobStatusScheduled = "Scheduled"
+	JobStatusRunning   = "Running"
+	JobStatusStopped   = "Stopped"
+	JobStatusError     = "Error"
+	JobStatusSuccess   = "Success"
+)
+
+// IsJobFinished reports whether the job status is a final status
+func IsJobFinished(status string) bool {
+	switch strings.ToLower(status) {
+	case "success", "error", "stopped", "finished":
+		return true
+	default:
+		return false
+	}
+}
+
+// ExecHistory same as harbor swagger 'ExecHistory' and 'GCHistory'
+type ExecHistory struct {
+	ID            int64        `json:"id"`
+	JobName       string       `json:"job_name"`
+	JobKind       string       `json:"job_kind"`
+	JobParameters string       `json:"job_parameters"` // json encoded parameters
+	Schedule      *ScheduleObj `json:"schedule,omitempty"`
+	JobStatus     string       `json:"job_status"`
+	Deleted       bool         `json:"deleted"`
+	CreationTime  time.Time    `json:"creation_time"`
+	UpdateTime    time.Time    `json:"update_time"`
+}
+
+// idFromLocation returns the resource id from 'Location' header,e.g. /api/v2.0/system/gc/12 -> 12
+func idFromLocation(location string) (int64, error) {
+	return strconv.ParseInt(path.Base(location), 10, 64)
+}
+
+// poll calls condition every interval until it returns true or error.
+// the interval doubles after each call when maxInterval greater than interval.
+func poll(ctx context.Context, interval, maxInterval time.Duration, condition func(ctx context.Context) (bool, error)) error {
+	for {
+		done, err := condition(ctx)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		if interval < maxInterval {
+			if interval *= 2; interval > maxInterval {
+				interval = maxInterval
+			}
+		}
+	}
+}
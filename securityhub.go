@@ -0,0 +1,158 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/goharbor/harbor/src/pkg/scan/vuln"
+)
+
+// SecuritySummary same as harbor swagger 'SecuritySummary'
+type SecuritySummary struct {
+	CriticalCnt        int64               `json:"critical_cnt"`
+	HighCnt            int64               `json:"high_cnt"`
+	MediumCnt          int64               `json:"medium_cnt"`
+	LowCnt             int64               `json:"low_cnt"`
+	NoneCnt            int64               `json:"none_cnt"`
+	UnknownCnt         int64               `json:"unknown_cnt"`
+	TotalVuls          int64               `json:"total_vuls"`
+	ScannedCnt         int64               `json:"scanned_cnt"`
+	TotalArtifact      int64               `json:"total_artifact"`
+	FixableCnt         int64               `json:"fixable_cnt"`
+	DangerousCVEs      []DangerousCVE      `json:"dangerous_cves,omitempty"`
+	DangerousArtifacts []DangerousArtifact `json:"dangerous_artifacts,omitempty"`
+}
+
+// DangerousCVE same as harbor swagger 'DangerousCVE'
+type DangerousCVE struct {
+	CVEID       string        `json:"cve_id"`
+	Severity    vuln.Severity `json:"severity"`
+	CVSSScoreV3 float64       `json:"cvss_score_v3"`
+	Desc        string        `json:"desc"`
+	Package     string        `json:"package"`
+	Version     string        `json:"version"`
+}
+
+// DangerousArtifact same as harbor swagger 'DangerousArtifact'
+type DangerousArtifact struct {
+	ProjectID      int64  `json:"project_id"`
+	RepositoryName string `json:"repository_name"`
+	Digest         string `json:"digest"`
+	CriticalCnt    int64  `json:"critical_cnt"`
+	HighCnt        int64  `json:"high_cnt"`
+	MediumCnt      int64  `json:"medium_cnt"`
+}
+
+// GetSecuritySummary returns the vulnerability summary of the whole instance,
+// withDangerous includes the top 5 dangerous CVEs and artifacts.
+// GET /vul/summary
+func (c *Client) GetSecuritySummary(ctx context.Context, withDangerous bool) (SecuritySummary, error) {
+	query := url.Values{
+		"with_dangerous_cve":      []string{strconv.FormatBool(withDangerous)},
+		"with_dangerous_artifact": []string{strconv.FormatBool(withDangerous)},
+	}
+	ret := SecuritySummary{}
+	if err := c.doRequest(ctx, http.MethodGet, "/vul/summary?"+query.Encode(), nil, &ret); err != nil {
+		return ret, err
+	}
+	return ret, nil
+}
+
+// SecurityVulnerability same as harbor swagger 'VulnerabilityItem' of security hub
+type SecurityVulnerability struct {
+	ProjectID      int64         `json:"project_id"`
+	RepositoryName string        `json:"repository_name"`
+	Digest         string        `json:"digest"`
+	Tags           []string      `json:"tags"`
+	CVEID          string        `json:"cve_id"`
+	Severity       vuln.Severity `json:"severity"`
+	CVSSV3Score    float64       `json:"cvss_v3_score"`
+	Package        string        `json:"package"`
+	Version        string        `json:"version"`
+	FixedVersion   string        `json:"fixed_version"`
+	Desc           string        `json:"desc"`
+	Links          []string      `json:"links"`
+}
+
+type SecurityVulnerabilityList struct {
+	Total int
+	Items []SecurityVulnerability
+	Next  string // link of the next page,empty if the last page
+}
+
+// SearchVulnerabilitiesOptions filters of security hub,empty fields are ignored.
+// The filters are added to CommonListOptions.Query.
+type SearchVulnerabilitiesOptions struct {
+	CommonListOptions
+	CVEID          string
+	Package        string
+	Severity       vuln.Severity
+	ProjectID      int64
+	RepositoryName string
+	Digest         string
+	Tag            string
+	// CVSSScoreMin and CVSSScoreMax are the range of CVSS v3 score,zero means no limit.
+	// They are whole numbers because harbor parses range bounds as integer or time only.
+	CVSSScoreMin int
+	CVSSScoreMax int
+	// TuneCount returns an estimated total count for large result
+	TuneCount bool
+	// WithTag returns the tags of the artifacts
+	WithTag bool
+}
+
+func (o *SearchVulnerabilitiesOptions) toQuery() url.Values {
+	query := Query()
+	if o.Query != nil {
+		query.conditions = append(query.conditions, o.Query.conditions...)
+	}
+	for _, kv := range [][2]string{
+		{"cve_id", o.CVEID},
+		{"package", o.Package},
+		{"severity", string(o.Severity)},
+		{"repository_name", o.RepositoryName},
+		{"digest", o.Digest},
+		{"tag", o.Tag},
+	} {
+		if kv[1] != "" {
+			query.Eq(kv[0], kv[1])
+		}
+	}
+	if o.ProjectID != 0 {
+		query.Eq("project_id", o.ProjectID)
+	}
+	if o.CVSSScoreMin != 0 || o.CVSSScoreMax != 0 {
+		var min, max interface{}
+		if o.CVSSScoreMin != 0 {
+			min = o.CVSSScoreMin
+		}
+		if o.CVSSScoreMax != 0 {
+			max = o.CVSSScoreMax
+		}
+		query.Range("cvss_score_v3", min, max)
+	}
+	options := o.CommonListOptions
+	options.Query = query
+	values := options.toQuery()
+	values.Set("tune_count", strconv.FormatBool(o.TuneCount))
+	values.Set("with_tag", strconv.FormatBool(o.WithTag))
+	return values
+}
+
+// SearchVulnerabilities lists the vulnerabilities of all artifacts in the instance,
+// e.g. find the artifacts affected by a CVE.
+// Increase options.Page to get the next page until SecurityVulnerabilityList.Next is empty.
+// GET /vuls
+func (c *Client) SearchVulnerabilities(ctx context.Context, options SearchVulnerabilitiesOptions) (SecurityVulnerabilityList, error) {
+	path := fmt.Sprintf("/vuls?%s", options.toQuery().Encode())
+	ret := SecurityVulnerabilityList{}
+	resp, err := c.doRequestWithResponse(ctx, http.MethodGet, path, nil, &ret.Items)
+	if err != nil {
+		return ret, err
+	}
+	ret.Total, ret.Next = parsePagination(resp)
+	return ret, nil
+}
@@ -1,8 +1,11 @@
 package client
 
 import (
+	"net/http"
 	"net/url"
 	"strconv"
+
+	"github.com/tomnomnom/linkheader"
 )
 
 // Error same as https://github.com/goharbor/harbor/blob/4e1f6633afb824cd16341044a0e82f4f1f230cd2/src/lib/errors/errors.go#L32
@@ -25,12 +28,45 @@ type CommonListOptions struct {
 	// The value of range and list can be string(enclosed by " or '),integer or time(in format "2020-04-09 02:36:00").
 	// All of these query patterns should be put in the query string "q=xxx" and splitted by ",". e.g. q=k1=v1,k2=~v2,k3=[min~max]
 	Q string `json:"q"`
+	// Query is the typed builder of 'q',it is joined with Q if both set.
+	Query *QueryBuilder `json:"-"`
+	// Sort the resource list in ascending or descending order.
+	// e.g. sort by field1 in ascending order and field2 in descending order with "sort=field1,-field2"
+	Sort string `json:"sort"`
 }
 
 func (o *CommonListOptions) toQuery() url.Values {
-	return url.Values{
+	values := url.Values{
 		"page":      []string{strconv.Itoa(o.Page)},
 		"page_size": []string{strconv.Itoa(o.Size)},
-		"q":         []string{o.Q},
+		"q":         []string{o.q()},
+	}
+	if o.Sort != "" {
+		values.Set("sort", o.Sort)
+	}
+	return values
+}
+
+func (o *CommonListOptions) q() string {
+	if o.Query == nil || len(o.Query.conditions) == 0 {
+		return o.Q
+	}
+	// harbor unescapes 'q' once more after url query decoded,
+	// escape the rendered query to keep '%' and '+' in values.
+	q := url.QueryEscape(o.Query.String())
+	if o.Q != "" {
+		q = o.Q + "," + q
+	}
+	return q
+}
+
+// parsePagination returns the total count from 'X-Total-Count' header and the next page link from 'Link' header
+func parsePagination(resp *http.Response) (total int, next string) {
+	total, _ = strconv.Atoi(resp.Header.Get(xTotalCountHeader))
+	for _, link := range linkheader.Parse(resp.Header.Get(linkHeader)) {
+		if link.Rel == "next" {
+			next = link.URL
+		}
 	}
+	return total, next
 }
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/goharbor/harbor/src/pkg/scan/vuln"
+)
+
+// VulnerabilityChange is a finding in the diff,it is identified by vulnerability id and package
+type VulnerabilityChange struct {
+	ID      string
+	Package string
+	Before  *vuln.VulnerabilityItem // nil if introduced
+	After   *vuln.VulnerabilityItem // nil if fixed
+	// SeverityDelta is the difference of severity code from Before to After,
+	// positive means severity raised.zero if introduced or fixed.
+	SeverityDelta int
+}
+
+// Severity returns the severity of the latest finding
+func (c VulnerabilityChange) Severity() vuln.Severity {
+	if c.After != nil {
+		return c.After.Severity
+	}
+	return c.Before.Severity
+}
+
+type VulnerabilityDiffSummary struct {
+	Introduced           int
+	Fixed                int
+	Unchanged            int
+	SeverityChanged      int // unchanged findings with a different severity
+	IntroducedBySeverity map[vuln.Severity]int
+	FixedBySeverity      map[vuln.Severity]int
+}
+
+// VulnerabilityDiff is the vulnerabilities diff from artifact A to artifact B,
+// findings are sorted by severity descending then by id and package.
+type VulnerabilityDiff struct {
+	Introduced []VulnerabilityChange // only in B
+	Fixed      []VulnerabilityChange // only in A
+	Unchanged  []VulnerabilityChange // in both A and B,package version may differ
+	Summary    VulnerabilityDiffSummary
+}
+
+// DiffVulnerabilities compares the vulnerabilities of two harbor images,
+// e.g. "harbor.example.com/library/app:v1" and "harbor.example.com/library/app:v2".
+func (c *Client) DiffVulnerabilities(ctx context.Context, refA, refB string) (*VulnerabilityDiff, error) {
+	reports := make([]Vulnerabilities, 0, 2)
+	for _, ref := range []string{refA, refB} {
+		project, repository, reference, err := ParseHarborSuitImage(ref)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", ref, err)
+		}
+		vulnerabilities, err := c.GetArtifactadditionVulnerabilities(ctx, project, repository, reference)
+		if err != nil {
+			return nil, fmt.Errorf("get vulnerabilities of %s: %w", ref, err)
+		}
+		reports = append(reports, vulnerabilities)
+	}
+	return DiffVulnerabilityReports(reports[0], reports[1]), nil
+}
+
+// DiffVulnerabilityReports compares two stored vulnerability reports
+func DiffVulnerabilityReports(a, b Vulnerabilities) *VulnerabilityDiff {
+	key := func(item *vuln.VulnerabilityItem) string { return item.ID + "\x00" + item.Package }
+
+	before := map[string]*vuln.VulnerabilityItem{}
+	for _, item := range a.Items() {
+		if _, ok := before[key(item)]; !ok {
+			before[key(item)] = item
+		}
+	}
+	diff := &VulnerabilityDiff{
+		Summary: VulnerabilityDiffSummary{
+			IntroducedBySeverity: map[vuln.Severity]int{},
+			FixedBySeverity:      map[vuln.Severity]int{},
+		},
+	}
+	after := map[string]bool{}
+	for _, item := range b.Items() {
+		k := key(item)
+		if after[k] {
+			continue
+		}
+		after[k] = true
+		prev, ok := before[k]
+		if !ok {
+			diff.Introduced = append(diff.Introduced, VulnerabilityChange{ID: item.ID, Package: item.Package, After: item})
+			diff.Summary.IntroducedBySeverity[item.Severity]++
+			continue
+		}
+		change := VulnerabilityChange{
+			ID:            item.ID,
+			Package:       item.Package,
+			Before:        prev,
+			After:         item,
+			SeverityDelta: item.Severity.Code() - prev.Severity.Code(),
+		}
+		if change.SeverityDelta != 0 {
+			diff.Summary.SeverityChanged++
+		}
+		diff.Unchanged = append(diff.Unchanged, change)
+	}
+	for k, item := range before {
+		if !after[k] {
+			diff.Fixed = append(diff.Fixed, VulnerabilityChange{ID: item.ID, Package: item.Package, Before: item})
+			diff.Summary.FixedBySeverity[item.Severity]++
+		}
+	}
+	for _, changes := range [][]VulnerabilityChange{diff.Introduced, diff.Fixed, diff.Unchanged} {
+		sortVulnerabilityChanges(changes)
+	}
+	diff.Summary.Introduced, diff.Summary.Fixed, diff.Summary.Unchanged = len(diff.Introduced), len(diff.Fixed), len(diff.Unchanged)
+	return diff
+}
+
+func sortVulnerabilityChanges(changes []VulnerabilityChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		si, sj := changes[i].Severity().Code(), changes[j].Severity().Code()
+		if si != sj {
+			return si > sj
+		}
+		if changes[i].ID != changes[j].ID {
+			return changes[i].ID < changes[j].ID
+		}
+		return changes[i].Package < changes[j].Package
+	})
+}
@@ -0,0 +1,54 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/goharbor/harbor/src/pkg/scan/vuln"
+)
+
+func TestDiffVulnerabilityReports(t *testing.T) {
+	v1 := Vulnerabilities{
+		testReportMimeType: vuln.Report{Vulnerabilities: []*vuln.VulnerabilityItem{
+			{ID: "CVE-2021-3711", Package: "openssl", Version: "1.1.1k", Severity: vuln.Critical},
+			{ID: "CVE-2021-33574", Package: "libc6", Version: "2.31-13", Severity: vuln.High},
+			{ID: "CVE-2019-19603", Package: "libsqlite3-0", Version: "3.34.1-3", Severity: vuln.Low},
+		}},
+	}
+	v2 := Vulnerabilities{
+		testReportMimeType: vuln.Report{Vulnerabilities: []*vuln.VulnerabilityItem{
+			{ID: "CVE-2021-33574", Package: "libc6", Version: "2.31-14", Severity: vuln.Critical},
+			{ID: "CVE-2019-19603", Package: "libsqlite3-0", Version: "3.34.1-3", Severity: vuln.Low},
+			{ID: "CVE-2022-0778", Package: "openssl", Version: "1.1.1m", Severity: vuln.High},
+			{ID: "CVE-2022-23218", Package: "libc6", Version: "2.31-14", Severity: vuln.Critical},
+		}},
+	}
+
+	diff := DiffVulnerabilityReports(v1, v2)
+
+	ids := func(changes []VulnerabilityChange) []string {
+		ret := []string{}
+		for _, change := range changes {
+			ret = append(ret, change.ID)
+		}
+		return ret
+	}
+	if got := ids(diff.Introduced); !equalStrings(got, []string{"CVE-2022-23218", "CVE-2022-0778"}) {
+		t.Errorf("introduced = %v", got)
+	}
+	if got := ids(diff.Fixed); !equalStrings(got, []string{"CVE-2021-3711"}) {
+		t.Errorf("fixed = %v", got)
+	}
+	if got := ids(diff.Unchanged); !equalStrings(got, []string{"CVE-2021-33574", "CVE-2019-19603"}) {
+		t.Errorf("unchanged = %v", got)
+	}
+	if delta := diff.Unchanged[0].SeverityDelta; delta != 1 {
+		t.Errorf("severity delta = %d", delta)
+	}
+	summary := diff.Summary
+	if summary.Introduced != 2 || summary.Fixed != 1 || summary.Unchanged != 2 || summary.SeverityChanged != 1 {
+		t.Errorf("summary = %+v", summary)
+	}
+	if summary.IntroducedBySeverity[vuln.Critical] != 1 || summary.FixedBySeverity[vuln.Critical] != 1 {
+		t.Errorf("summary by severity = %+v", summary)
+	}
+}
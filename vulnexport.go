@@ -0,0 +1,466 @@
+package client
+
+import (
+	"crypto/sha1"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goharbor/harbor/src/pkg/scan/vuln"
+)
+
+// ReportArtifact is the artifact context of exported vulnerability reports
+type ReportArtifact struct {
+	Repository string // full repository name,e.g. harbor.example.com/library/nginx
+	Tag        string
+	Digest     string // e.g. sha256:954b378c375d852eb3c63ab88978f640b4348b01c1b3456a024a81536dafbbf4
+}
+
+func (a ReportArtifact) String() string {
+	switch {
+	case a.Digest != "":
+		return a.Repository + "@" + a.Digest
+	case a.Tag != "":
+		return a.Repository + ":" + a.Tag
+	default:
+		return a.Repository
+	}
+}
+
+// scanner returns the scanner of reports sorted by mime type
+func (v Vulnerabilities) scanner() (name, vendor, version, generatedAt string) {
+	mimetypes := make([]string, 0, len(v))
+	for mimetype := range v {
+		mimetypes = append(mimetypes, mimetype)
+	}
+	sort.Strings(mimetypes)
+	for _, mimetype := range mimetypes {
+		report := v[mimetype]
+		if report.Scanner != nil {
+			return report.Scanner.Name, report.Scanner.Vendor, report.Scanner.Version, report.GeneratedAt
+		}
+	}
+	return "Harbor", "Harbor", "", ""
+}
+
+// EncodeVulnerabilitiesCSV writes vulnerabilities as CSV with a header line
+func EncodeVulnerabilitiesCSV(w io.Writer, vulnerabilities Vulnerabilities, artifact ReportArtifact) error {
+	writer := csv.NewWriter(w)
+	header := []string{
+		"repository", "tag", "digest", "vulnerability", "package", "version", "fix_version",
+		"severity", "cvss_v3_score", "cvss_v2_score", "cwe_ids", "links", "description",
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, item := range vulnerabilities.Items() {
+		record := []string{
+			artifact.Repository, artifact.Tag, artifact.Digest,
+			item.ID, item.Package, item.Version, item.FixVersion, string(item.Severity),
+			formatScore(item.CVSSDetails.ScoreV3), formatScore(item.CVSSDetails.ScoreV2),
+			strings.Join(item.CWEIds, ";"), strings.Join(item.Links, ";"), item.Description,
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatScore(score *float64) string {
+	if score == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*score, 'f', -1, 64)
+}
+
+// SARIF 2.1.0
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool              `json:"tool"`
+	Results    []sarifResult          `json:"results"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Organization   string      `json:"organization,omitempty"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string                 `json:"id"`
+	Name                 string                 `json:"name"`
+	ShortDescription     sarifMessage           `json:"shortDescription"`
+	FullDescription      sarifMessage           `json:"fullDescription"`
+	DefaultConfiguration sarifConfiguration     `json:"defaultConfiguration"`
+	HelpURI              string                 `json:"helpUri,omitempty"`
+	Help                 sarifMessage           `json:"help"`
+	Properties           map[string]interface{} `json:"properties"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	Message          sarifMessage          `json:"message"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+// sarifLevel maps harbor severity to SARIF result level
+func sarifLevel(severity vuln.Severity) string {
+	switch severity {
+	case vuln.Critical, vuln.High:
+		return "error"
+	case vuln.Medium:
+		return "warning"
+	case vuln.Low, vuln.Negligible, vuln.Unknown:
+		return "note"
+	default:
+		return "none"
+	}
+}
+
+// securitySeverity returns the 'security-severity' property,
+// the CVSS score or the middle score of the severity if no CVSS score.
+func securitySeverity(item *vuln.VulnerabilityItem) string {
+	if score, ok := cvssScore(item); ok {
+		return strconv.FormatFloat(score, 'f', 1, 64)
+	}
+	switch item.Severity {
+	case vuln.Critical:
+		return "9.5"
+	case vuln.High:
+		return "8.0"
+	case vuln.Medium:
+		return "5.5"
+	case vuln.Low:
+		return "2.0"
+	default:
+		return "0.0"
+	}
+}
+
+// EncodeVulnerabilitiesSARIF writes vulnerabilities as SARIF 2.1.0 log,
+// each vulnerability id is a rule and each affected package is a result.
+func EncodeVulnerabilitiesSARIF(w io.Writer, vulnerabilities Vulnerabilities, artifact ReportArtifact) error {
+	name, vendor, version, _ := vulnerabilities.scanner()
+	run := sarifRun{
+		Tool:    sarifTool{Driver: sarifDriver{Name: name, Organization: vendor, Version: version, Rules: []sarifRule{}}},
+		Results: []sarifResult{},
+		Properties: map[string]interface{}{
+			"imageName": artifact.Repository,
+		},
+	}
+	if artifact.Digest != "" {
+		run.Properties["repoDigests"] = []string{artifact.Repository + "@" + artifact.Digest}
+	}
+	if artifact.Tag != "" {
+		run.Properties["repoTags"] = []string{artifact.Repository + ":" + artifact.Tag}
+	}
+
+	ruleIndex := map[string]int{}
+	for _, item := range vulnerabilities.Items() {
+		index, ok := ruleIndex[item.ID]
+		if !ok {
+			index = len(run.Tool.Driver.Rules)
+			ruleIndex[item.ID] = index
+			rule := sarifRule{
+				ID:                   item.ID,
+				Name:                 "OsPackageVulnerability",
+				ShortDescription:     sarifMessage{Text: item.ID},
+				FullDescription:      sarifMessage{Text: item.Description},
+				DefaultConfiguration: sarifConfiguration{Level: sarifLevel(item.Severity)},
+				Help: sarifMessage{Text: fmt.Sprintf("Vulnerability %s\nSeverity: %s\nPackage: %s\nFixed Version: %s\nLink: %s",
+					item.ID, item.Severity, item.Package, item.FixVersion, strings.Join(item.Links, " "))},
+				Properties: map[string]interface{}{
+					"precision":         "very-high",
+					"security-severity": securitySeverity(item),
+					"tags":              []string{"vulnerability", "security", strings.ToUpper(string(item.Severity))},
+				},
+			}
+			if len(item.Links) > 0 {
+				rule.HelpURI = item.Links[0]
+			}
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    item.ID,
+			RuleIndex: index,
+			Level:     sarifLevel(item.Severity),
+			Message: sarifMessage{Text: fmt.Sprintf("Package: %s\nInstalled Version: %s\nVulnerability %s\nSeverity: %s\nFixed Version: %s",
+				item.Package, item.Version, item.ID, item.Severity, item.FixVersion)},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: artifact.Repository, URIBaseID: "ROOTPATH"},
+					Region:           sarifRegion{StartLine: 1, StartColumn: 1, EndLine: 1, EndColumn: 1},
+				},
+				Message: sarifMessage{Text: fmt.Sprintf("%s: %s@%s", artifact, item.Package, item.Version)},
+			}},
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
+
+// CycloneDX 1.4
+// https://cyclonedx.org/docs/1.4/json/
+type cdxBOM struct {
+	BOMFormat       string             `json:"bomFormat"`
+	SpecVersion     string             `json:"specVersion"`
+	SerialNumber    string             `json:"serialNumber"`
+	Version         int                `json:"version"`
+	Metadata        cdxMetadata        `json:"metadata"`
+	Components      []cdxComponent     `json:"components"`
+	Vulnerabilities []cdxVulnerability `json:"vulnerabilities"`
+}
+
+type cdxMetadata struct {
+	Timestamp string        `json:"timestamp,omitempty"`
+	Tools     []cdxTool     `json:"tools"`
+	Component *cdxComponent `json:"component,omitempty"`
+}
+
+type cdxTool struct {
+	Vendor  string `json:"vendor,omitempty"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type cdxComponent struct {
+	BOMRef  string `json:"bom-ref"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	PURL    string `json:"purl,omitempty"`
+}
+
+type cdxVulnerability struct {
+	ID             string        `json:"id"`
+	Source         *cdxSource    `json:"source,omitempty"`
+	Ratings        []cdxRating   `json:"ratings"`
+	CWEs           []int         `json:"cwes,omitempty"`
+	Description    string        `json:"description,omitempty"`
+	Recommendation string        `json:"recommendation,omitempty"`
+	Advisories     []cdxAdvisory `json:"advisories,omitempty"`
+	Analysis       *cdxAnalysis  `json:"analysis,omitempty"`
+	Affects        []cdxAffect   `json:"affects"`
+}
+
+// cdxAnalysis is the VEX impact analysis of a vulnerability
+type cdxAnalysis struct {
+	State    string   `json:"state"`
+	Response []string `json:"response,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+type cdxSource struct {
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
+type cdxRating struct {
+	Score    *float64 `json:"score,omitempty"`
+	Severity string   `json:"severity"`
+	Method   string   `json:"method,omitempty"`
+	Vector   string   `json:"vector,omitempty"`
+}
+
+type cdxAdvisory struct {
+	URL string `json:"url"`
+}
+
+type cdxAffect struct {
+	Ref      string              `json:"ref"`
+	Versions []cdxAffectedStatus `json:"versions"`
+}
+
+type cdxAffectedStatus struct {
+	Version string `json:"version"`
+	Status  string `json:"status"`
+}
+
+// cdxSeverity maps harbor severity to CycloneDX severity
+func cdxSeverity(severity vuln.Severity) string {
+	switch severity {
+	case vuln.Critical, vuln.High, vuln.Medium, vuln.Low, vuln.None:
+		return strings.ToLower(string(severity))
+	case vuln.Negligible:
+		return "info"
+	default:
+		return "unknown"
+	}
+}
+
+func cdxRatings(item *vuln.VulnerabilityItem) []cdxRating {
+	severity := cdxSeverity(item.Severity)
+	ratings := []cdxRating{}
+	if score := item.CVSSDetails.ScoreV3; score != nil {
+		method := "CVSSv3"
+		if strings.HasPrefix(item.CVSSDetails.VectorV3, "CVSS:3.1/") {
+			method = "CVSSv31"
+		}
+		ratings = append(ratings, cdxRating{Score: score, Severity: severity, Method: method, Vector: item.CVSSDetails.VectorV3})
+	}
+	if score := item.CVSSDetails.ScoreV2; score != nil {
+		ratings = append(ratings, cdxRating{Score: score, Severity: severity, Method: "CVSSv2", Vector: item.CVSSDetails.VectorV2})
+	}
+	if len(ratings) == 0 {
+		ratings = append(ratings, cdxRating{Severity: severity})
+	}
+	return ratings
+}
+
+// ociPURL returns the package url of the artifact
+// https://github.com/package-url/purl-spec/blob/master/PURL-TYPES.rst#oci
+func ociPURL(artifact ReportArtifact) string {
+	if artifact.Digest == "" {
+		return ""
+	}
+	qualifiers := url.Values{}
+	qualifiers.Set("repository_url", artifact.Repository)
+	if artifact.Tag != "" {
+		qualifiers.Set("tag", artifact.Tag)
+	}
+	return fmt.Sprintf("pkg:oci/%s@%s?%s", path.Base(artifact.Repository), strings.ReplaceAll(artifact.Digest, ":", "%3A"), qualifiers.Encode())
+}
+
+// uuidNamespaceURL is the RFC 4122 name space of URLs,6ba7b811-9dad-11d1-80b4-00c04fd430c8
+var uuidNamespaceURL = []byte{0x6b, 0xa7, 0xb8, 0x11, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+
+// uuidFromContent returns the RFC 4122 version 5 uuid of content in URL name space,so the output is reproducible
+func uuidFromContent(content string) string {
+	hash := sha1.New()
+	hash.Write(uuidNamespaceURL)
+	hash.Write([]byte(content))
+	sum := hash.Sum(nil)
+	sum[6] = (sum[6] & 0x0f) | 0x50 // version 5
+	sum[8] = (sum[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// EncodeVulnerabilitiesCycloneDX writes vulnerabilities as CycloneDX 1.4 vulnerability document(BOV/VEX),
+// the artifact is the metadata component and each affected package is a component.
+func EncodeVulnerabilitiesCycloneDX(w io.Writer, vulnerabilities Vulnerabilities, artifact ReportArtifact) error {
+	name, vendor, version, generatedAt := vulnerabilities.scanner()
+	bom := cdxBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.4",
+		SerialNumber: "urn:uuid:" + uuidFromContent(artifact.String()+generatedAt),
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: generatedAt,
+			Tools:     []cdxTool{{Vendor: vendor, Name: name, Version: version}},
+			Component: &cdxComponent{
+				BOMRef:  artifact.String(),
+				Type:    "container",
+				Name:    artifact.Repository,
+				Version: artifact.Digest,
+				PURL:    ociPURL(artifact),
+			},
+		},
+		Components:      []cdxComponent{},
+		Vulnerabilities: []cdxVulnerability{},
+	}
+
+	components, vulnindex := map[string]bool{}, map[string]int{}
+	for _, item := range vulnerabilities.Items() {
+		ref := item.Package + "@" + item.Version
+		if !components[ref] {
+			components[ref] = true
+			bom.Components = append(bom.Components, cdxComponent{BOMRef: ref, Type: "library", Name: item.Package, Version: item.Version})
+		}
+		affect := cdxAffect{Ref: ref, Versions: []cdxAffectedStatus{{Version: item.Version, Status: "affected"}}}
+		// one vulnerability entry per id,affects all packages
+		if index, ok := vulnindex[item.ID]; ok {
+			bom.Vulnerabilities[index].Affects = append(bom.Vulnerabilities[index].Affects, affect)
+			continue
+		}
+		vulnerability := cdxVulnerability{
+			ID:          item.ID,
+			Ratings:     cdxRatings(item),
+			Description: item.Description,
+			// the scanner detects vulnerabilities only,the exploitability is not assessed yet
+			Analysis: &cdxAnalysis{State: "in_triage", Detail: fmt.Sprintf("Detected by %s", name)},
+			Affects:  []cdxAffect{affect},
+		}
+		if len(item.Links) > 0 {
+			vulnerability.Source = &cdxSource{URL: item.Links[0]}
+			for _, link := range item.Links {
+				vulnerability.Advisories = append(vulnerability.Advisories, cdxAdvisory{URL: link})
+			}
+		}
+		for _, cwe := range item.CWEIds {
+			if id, err := strconv.Atoi(strings.TrimPrefix(cwe, "CWE-")); err == nil {
+				vulnerability.CWEs = append(vulnerability.CWEs, id)
+			}
+		}
+		if item.FixVersion != "" {
+			vulnerability.Recommendation = fmt.Sprintf("Upgrade %s to version %s", item.Package, item.FixVersion)
+			vulnerability.Analysis.Response = []string{"update"}
+		}
+		vulnindex[item.ID] = len(bom.Vulnerabilities)
+		bom.Vulnerabilities = append(bom.Vulnerabilities, vulnerability)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(bom)
+}
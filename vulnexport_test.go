@@ -0,0 +1,66 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files in testdata")
+
+func TestEncodeVulnerabilities(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("testdata", "vulnerabilities.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vulnerabilities := Vulnerabilities{}
+	if err := json.Unmarshal(content, &vulnerabilities); err != nil {
+		t.Fatal(err)
+	}
+	artifact := ReportArtifact{
+		Repository: "harbor.example.com/library/nginx",
+		Tag:        "1.21",
+		Digest:     "sha256:954b378c375d852eb3c63ab88978f640b4348b01c1b3456a024a81536dafbbf4",
+	}
+
+	tests := []struct {
+		golden string
+		encode func(io.Writer, Vulnerabilities, ReportArtifact) error
+	}{
+		{golden: "vulnerabilities.sarif.json", encode: EncodeVulnerabilitiesSARIF},
+		{golden: "vulnerabilities.cdx.json", encode: EncodeVulnerabilitiesCycloneDX},
+		{golden: "vulnerabilities.csv", encode: EncodeVulnerabilitiesCSV},
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if err := tt.encode(buf, vulnerabilities, artifact); err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", tt.golden)
+			if *updateGolden {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("output mismatch %s, run 'go test -run TestEncodeVulnerabilities -update' to update\n%s", golden, buf.String())
+			}
+		})
+	}
+}
+
+func TestUUIDFromContent(t *testing.T) {
+	// uuid.uuid5(uuid.NAMESPACE_URL, ...) of python
+	if got, want := uuidFromContent("harbor.example.com/library/nginx@sha256:abc"), "eb5747f7-f2c6-55fa-8890-aeae74990df3"; got != want {
+		t.Errorf("uuidFromContent() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,129 @@
+package client
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/goharbor/harbor/src/pkg/scan/vuln"
+)
+
+// AllowedCVE is a CVE allowlist entry of VulnerabilityPolicy
+type AllowedCVE struct {
+	ID        string    // e.g. CVE-2021-44228
+	ExpiresAt time.Time // zero means never expire
+	Reason    string
+}
+
+// VulnerabilityPolicy decides whether an artifact passes the gate by its vulnerability reports.
+//
+// A finding violates the policy when its severity reaches Severity or its CVSS score reaches CVSSScore.
+// Findings in Allowlist or of packages matching IgnorePackages never violate.
+type VulnerabilityPolicy struct {
+	// Severity is the lowest severity violates the policy,
+	// empty,None or Unknown means no severity threshold.
+	Severity vuln.Severity
+	// CVSSScore is the lowest CVSS score violates the policy,zero means no score limit.
+	// CVSS v3 score is used,fallback to v2 score.
+	CVSSScore float64
+	// FixableOnly only findings have a fix version can violate the policy.
+	FixableOnly bool
+	// Allowlist of CVEs,expired entries are ignored.
+	Allowlist []AllowedCVE
+	// IgnorePackages are package name patterns in path.Match syntax,e.g. "linux-libc-*".
+	IgnorePackages []string
+	// Now is the time to check allowlist expiry,zero means time.Now().
+	Now time.Time
+}
+
+type PolicyViolation struct {
+	Vulnerability *vuln.VulnerabilityItem
+	Reasons       []string // e.g. "severity High >= High","cvss 9.8 >= 7"
+}
+
+type PolicyVerdict struct {
+	Passed     bool
+	Violations []PolicyViolation
+	// Allowed findings would violate the policy but in allowlist
+	Allowed []*vuln.VulnerabilityItem
+	// Ignored findings of ignored packages
+	Ignored []*vuln.VulnerabilityItem
+	// ExpiredAllowlist entries are expired and not applied
+	ExpiredAllowlist []AllowedCVE
+}
+
+func (v PolicyVerdict) String() string {
+	if v.Passed {
+		return "passed"
+	}
+	ids := make([]string, 0, len(v.Violations))
+	for _, violation := range v.Violations {
+		ids = append(ids, violation.Vulnerability.ID)
+	}
+	return fmt.Sprintf("failed: %d violations: %s", len(v.Violations), strings.Join(ids, ","))
+}
+
+// Evaluate the vulnerability reports,e.g. from GetArtifactadditionVulnerabilities or stored reports.
+func (p VulnerabilityPolicy) Evaluate(vulnerabilities Vulnerabilities) PolicyVerdict {
+	now := p.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	verdict := PolicyVerdict{}
+	allowed := map[string]bool{}
+	for _, entry := range p.Allowlist {
+		if !entry.ExpiresAt.IsZero() && !now.Before(entry.ExpiresAt) {
+			verdict.ExpiredAllowlist = append(verdict.ExpiredAllowlist, entry)
+			continue
+		}
+		allowed[entry.ID] = true
+	}
+
+	for _, item := range vulnerabilities.Items() {
+		if p.ignored(item.Package) {
+			verdict.Ignored = append(verdict.Ignored, item)
+			continue
+		}
+		reasons := p.violates(item)
+		if len(reasons) == 0 {
+			continue
+		}
+		if allowed[item.ID] {
+			verdict.Allowed = append(verdict.Allowed, item)
+			continue
+		}
+		verdict.Violations = append(verdict.Violations, PolicyViolation{Vulnerability: item, Reasons: reasons})
+	}
+	verdict.Passed = len(verdict.Violations) == 0
+	return verdict
+}
+
+func (p VulnerabilityPolicy) ignored(pkg string) bool {
+	for _, pattern := range p.IgnorePackages {
+		if matched, _ := path.Match(pattern, pkg); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (p VulnerabilityPolicy) violates(item *vuln.VulnerabilityItem) []string {
+	if p.FixableOnly && item.FixVersion == "" {
+		return nil
+	}
+	reasons := []string{}
+	if p.hasSeverityThreshold() && item.Severity.Code() >= p.Severity.Code() {
+		reasons = append(reasons, fmt.Sprintf("severity %s >= %s", item.Severity, p.Severity))
+	}
+	if score, ok := cvssScore(item); ok && p.CVSSScore > 0 && score >= p.CVSSScore {
+		reasons = append(reasons, fmt.Sprintf("cvss %g >= %g", score, p.CVSSScore))
+	}
+	return reasons
+}
+
+// hasSeverityThreshold reports whether Severity is a threshold,
+// None and Unknown are the lowest codes and would make all findings violate.
+func (p VulnerabilityPolicy) hasSeverityThreshold() bool {
+	return p.Severity != "" && p.Severity != vuln.None && p.Severity != vuln.Unknown
+}
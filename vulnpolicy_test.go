@@ -0,0 +1,120 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goharbor/harbor/src/pkg/scan/vuln"
+)
+
+func float64ptr(f float64) *float64 { return &f }
+
+func testVulnerabilities() Vulnerabilities {
+	return Vulnerabilities{
+		testReportMimeType: vuln.Report{
+			Severity: vuln.Critical,
+			Vulnerabilities: []*vuln.VulnerabilityItem{
+				{ID: "CVE-2021-44228", Package: "log4j-core", Version: "2.14.1", FixVersion: "2.15.0", Severity: vuln.Critical,
+					CVSSDetails: vuln.CVSS{ScoreV3: float64ptr(10)}},
+				{ID: "CVE-2022-0001", Package: "openssl", Version: "1.1.1k", Severity: vuln.High,
+					CVSSDetails: vuln.CVSS{ScoreV2: float64ptr(7.2)}},
+				{ID: "CVE-2022-0002", Package: "linux-libc-dev", Version: "5.10", FixVersion: "5.11", Severity: vuln.High},
+				{ID: "CVE-2022-0003", Package: "zlib", Version: "1.2.11", FixVersion: "1.2.12", Severity: vuln.Medium,
+					CVSSDetails: vuln.CVSS{ScoreV3: float64ptr(7.5)}},
+				{ID: "CVE-2022-0004", Package: "busybox", Version: "1.33", Severity: vuln.Low},
+			},
+		},
+	}
+}
+
+func violationIDs(verdict PolicyVerdict) []string {
+	ids := []string{}
+	for _, violation := range verdict.Violations {
+		ids = append(ids, violation.Vulnerability.ID)
+	}
+	return ids
+}
+
+func TestVulnerabilityPolicy_Evaluate(t *testing.T) {
+	now := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		policy     VulnerabilityPolicy
+		want       []string
+		allowed    int
+		ignored    int
+		expired    int
+		wantPassed bool
+	}{
+		{
+			name:   "severity threshold",
+			policy: VulnerabilityPolicy{Severity: vuln.High},
+			want:   []string{"CVE-2021-44228", "CVE-2022-0001", "CVE-2022-0002"},
+		},
+		{
+			name:       "none and unknown are no threshold",
+			policy:     VulnerabilityPolicy{Severity: vuln.Unknown},
+			want:       []string{},
+			wantPassed: true,
+		},
+		{
+			name:   "fixable only",
+			policy: VulnerabilityPolicy{Severity: vuln.High, FixableOnly: true},
+			want:   []string{"CVE-2021-44228", "CVE-2022-0002"},
+		},
+		{
+			name:   "cvss score",
+			policy: VulnerabilityPolicy{CVSSScore: 7.5},
+			want:   []string{"CVE-2021-44228", "CVE-2022-0003"},
+		},
+		{
+			name: "allowlist and ignored packages",
+			policy: VulnerabilityPolicy{
+				Severity: vuln.High,
+				Allowlist: []AllowedCVE{
+					{ID: "CVE-2021-44228", ExpiresAt: now.Add(time.Hour)},
+					{ID: "CVE-2022-0001", ExpiresAt: now.Add(-time.Hour)},
+				},
+				IgnorePackages: []string{"linux-libc-*"},
+				Now:            now,
+			},
+			want:    []string{"CVE-2022-0001"},
+			allowed: 1,
+			ignored: 1,
+			expired: 1,
+		},
+		{
+			name:       "passed",
+			policy:     VulnerabilityPolicy{Severity: vuln.Critical, Allowlist: []AllowedCVE{{ID: "CVE-2021-44228"}}},
+			want:       []string{},
+			allowed:    1,
+			wantPassed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict := tt.policy.Evaluate(testVulnerabilities())
+			if got := violationIDs(verdict); !equalStrings(got, tt.want) {
+				t.Errorf("violations = %v, want %v", got, tt.want)
+			}
+			if verdict.Passed != tt.wantPassed {
+				t.Errorf("passed = %v, want %v", verdict.Passed, tt.wantPassed)
+			}
+			if len(verdict.Allowed) != tt.allowed || len(verdict.Ignored) != tt.ignored || len(verdict.ExpiredAllowlist) != tt.expired {
+				t.Errorf("allowed = %d, ignored = %d, expired = %d", len(verdict.Allowed), len(verdict.Ignored), len(verdict.ExpiredAllowlist))
+			}
+		})
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
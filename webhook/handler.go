@@ -0,0 +1,255 @@
+// Package webhook receives harbor webhook notifications.
+//
+// Both harbor 'Default' and 'CloudEvents' payload format are supported.
+// For more information visit below URL
+// https://goharbor.io/docs/main/working-with-projects/project-configuration/configure-webhooks/
+package webhook
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	authorizationHeader = "Authorization"
+	// CloudEvents binary content mode headers
+	ceSpecVersionHeader = "Ce-Specversion"
+	ceTypeHeader        = "Ce-Type"
+	ceIDHeader          = "Ce-Id"
+	ceSourceHeader      = "Ce-Source"
+	ceTimeHeader        = "Ce-Time"
+	ceOperatorHeader    = "Ce-Operator"
+)
+
+var ErrUnknownEventType = errors.New("unknown webhook event type")
+
+type (
+	ArtifactHandlerFunc    func(ctx context.Context, event *ArtifactEvent) error
+	ScanHandlerFunc        func(ctx context.Context, event *ScanEvent) error
+	ReplicationHandlerFunc func(ctx context.Context, event *ReplicationEvent) error
+	QuotaHandlerFunc       func(ctx context.Context, event *QuotaEvent) error
+	RetentionHandlerFunc   func(ctx context.Context, event *RetentionEvent) error
+)
+
+// Handler is a http.Handler verifies and decodes harbor webhook requests
+// then dispatches typed events to registered callbacks.
+type Handler struct {
+	// AuthHeader is the 'Auth Header' configured in harbor webhook policy,
+	// requests not carry the same 'Authorization' header value are rejected.
+	// Empty means no verification.
+	AuthHeader string
+
+	onArtifact    []ArtifactHandlerFunc
+	onScan        []ScanHandlerFunc
+	onReplication []ReplicationHandlerFunc
+	onQuota       []QuotaHandlerFunc
+	onRetention   []RetentionHandlerFunc
+}
+
+func NewHandler(authHeader string) *Handler {
+	return &Handler{AuthHeader: authHeader}
+}
+
+// OnArtifact registers callback for PUSH_ARTIFACT,PULL_ARTIFACT and DELETE_ARTIFACT events
+func (h *Handler) OnArtifact(fn ArtifactHandlerFunc) *Handler {
+	h.onArtifact = append(h.onArtifact, fn)
+	return h
+}
+
+// OnScan registers callback for SCANNING_COMPLETED,SCANNING_FAILED and SCANNING_STOPPED events
+func (h *Handler) OnScan(fn ScanHandlerFunc) *Handler {
+	h.onScan = append(h.onScan, fn)
+	return h
+}
+
+// OnReplication registers callback for REPLICATION events
+func (h *Handler) OnReplication(fn ReplicationHandlerFunc) *Handler {
+	h.onReplication = append(h.onReplication, fn)
+	return h
+}
+
+// OnQuota registers callback for QUOTA_EXCEED and QUOTA_WARNING events
+func (h *Handler) OnQuota(fn QuotaHandlerFunc) *Handler {
+	h.onQuota = append(h.onQuota, fn)
+	return h
+}
+
+// OnRetention registers callback for TAG_RETENTION events
+func (h *Handler) OnRetention(fn RetentionHandlerFunc) *Handler {
+	h.onRetention = append(h.onRetention, fn)
+	return h
+}
+
+// ServeHTTP responds 204 for event types unknown to the handler,
+// harbor does not retry them then; 400 is kept for malformed requests.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.verify(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	event, err := Parse(r)
+	if err != nil {
+		if errors.Is(err, ErrUnknownEventType) {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.Dispatch(r.Context(), event); err != nil {
+		if errors.Is(err, ErrUnknownEventType) {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) verify(r *http.Request) bool {
+	if h.AuthHeader == "" {
+		return true
+	}
+	got := r.Header.Get(authorizationHeader)
+	return subtle.ConstantTimeCompare([]byte(got), []byte(h.AuthHeader)) == 1
+}
+
+// Dispatch calls the registered callbacks of the event type in registration order,
+// it stops on the first callback error.
+func (h *Handler) Dispatch(ctx context.Context, event *Event) error {
+	data := event.Data
+	repository := Repository{}
+	if data.Repository != nil {
+		repository = *data.Repository
+	}
+	switch event.Type {
+	case EventTypePushArtifact, EventTypePullArtifact, EventTypeDeleteArtifact:
+		typed := &ArtifactEvent{Event: *event, Repository: repository, Resources: data.Resources}
+		for _, fn := range h.onArtifact {
+			if err := fn(ctx, typed); err != nil {
+				return err
+			}
+		}
+	case EventTypeScanningCompleted, EventTypeScanningFailed, EventTypeScanningStopped:
+		typed := &ScanEvent{Event: *event, Repository: repository, Resources: data.Resources}
+		for _, fn := range h.onScan {
+			if err := fn(ctx, typed); err != nil {
+				return err
+			}
+		}
+	case EventTypeReplication:
+		typed := &ReplicationEvent{Event: *event}
+		if data.Replication != nil {
+			typed.Replication = *data.Replication
+		}
+		for _, fn := range h.onReplication {
+			if err := fn(ctx, typed); err != nil {
+				return err
+			}
+		}
+	case EventTypeQuotaExceed, EventTypeQuotaWarning:
+		typed := &QuotaEvent{Event: *event, Repository: repository, Resources: data.Resources, Details: data.Custom["Details"]}
+		for _, fn := range h.onQuota {
+			if err := fn(ctx, typed); err != nil {
+				return err
+			}
+		}
+	case EventTypeTagRetention:
+		typed := &RetentionEvent{Event: *event}
+		if data.Retention != nil {
+			typed.Retention = *data.Retention
+		}
+		for _, fn := range h.onRetention {
+			if err := fn(ctx, typed); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownEventType, event.Type)
+	}
+	return nil
+}
+
+// envelope contains fields of both default and CloudEvents structured payload,
+// 'type' and 'operator' are shared by both formats.
+type envelope struct {
+	Payload
+	// CloudEvents format
+	SpecVersion string     `json:"specversion"`
+	ID          string     `json:"id"`
+	Source      string     `json:"source"`
+	Time        string     `json:"time"`
+	Data        *EventData `json:"data"`
+}
+
+// Parse decodes a harbor webhook request into Event.
+// It accepts default payload, CloudEvents structured content mode and CloudEvents binary content mode.
+func Parse(r *http.Request) (*Event, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	// binary content mode, attributes in headers and body is the data
+	if specversion := r.Header.Get(ceSpecVersionHeader); specversion != "" {
+		data := EventData{}
+		if err := json.Unmarshal(body, &data); err != nil {
+			return nil, fmt.Errorf("decode cloudevents data: %w", err)
+		}
+		return cloudEvent(r.Header.Get(ceTypeHeader), r.Header.Get(ceIDHeader), r.Header.Get(ceSourceHeader),
+			r.Header.Get(ceTimeHeader), r.Header.Get(ceOperatorHeader), &data)
+	}
+
+	env := envelope{}
+	if err := json.Unmarshal(body, &env); err != nil {
+		return nil, fmt.Errorf("decode webhook payload: %w", err)
+	}
+	if env.SpecVersion != "" || strings.HasPrefix(r.Header.Get("Content-Type"), "application/cloudevents+json") {
+		return cloudEvent(string(env.Type), env.ID, env.Source, env.Time, env.Operator, env.Data)
+	}
+
+	event := &Event{
+		Type:     env.Type,
+		OccurAt:  time.Unix(env.OccurAt, 0),
+		Operator: env.Operator,
+	}
+	if env.EventData != nil {
+		event.Data = *env.EventData
+	}
+	return event, nil
+}
+
+func cloudEvent(cetype, id, source, cetime, operator string, data *EventData) (*Event, error) {
+	eventtype, ok := cloudEventTypes[cetype]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEventType, cetype)
+	}
+	event := &Event{
+		Type:     eventtype,
+		ID:       id,
+		Source:   source,
+		Operator: operator,
+	}
+	if cetime != "" {
+		occurat, err := time.Parse(time.RFC3339Nano, cetime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cloudevents time %s: %w", cetime, err)
+		}
+		event.OccurAt = occurat
+	}
+	if data != nil {
+		event.Data = *data
+	}
+	return event, nil
+}
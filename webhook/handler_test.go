@@ -0,0 +1,201 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testAuthHeader = "Bearer webhook-secret"
+
+func newFixtureRequest(t *testing.T, fixture string, contenttype string) *http.Request {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(body))
+	req.Header.Set("Content-Type", contenttype)
+	req.Header.Set("Authorization", testAuthHeader)
+	return req
+}
+
+func TestHandler_Dispatch(t *testing.T) {
+	tests := []struct {
+		fixture     string
+		contenttype string
+		wantType    EventType
+	}{
+		{fixture: "push_artifact.json", contenttype: "application/json", wantType: EventTypePushArtifact},
+		{fixture: "delete_artifact.json", contenttype: "application/json", wantType: EventTypeDeleteArtifact},
+		{fixture: "scanning_completed.json", contenttype: "application/json", wantType: EventTypeScanningCompleted},
+		{fixture: "scanning_failed.json", contenttype: "application/json", wantType: EventTypeScanningFailed},
+		{fixture: "replication.json", contenttype: "application/json", wantType: EventTypeReplication},
+		{fixture: "quota_exceed.json", contenttype: "application/json", wantType: EventTypeQuotaExceed},
+		{fixture: "tag_retention.json", contenttype: "application/json", wantType: EventTypeTagRetention},
+		{fixture: "cloudevents_push_artifact.json", contenttype: "application/cloudevents+json", wantType: EventTypePushArtifact},
+		{fixture: "cloudevents_scan_completed.json", contenttype: "application/cloudevents+json", wantType: EventTypeScanningCompleted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			got := EventType("")
+			record := func(e Event) { got = e.Type }
+			h := NewHandler(testAuthHeader).
+				OnArtifact(func(ctx context.Context, e *ArtifactEvent) error { record(e.Event); return nil }).
+				OnScan(func(ctx context.Context, e *ScanEvent) error { record(e.Event); return nil }).
+				OnReplication(func(ctx context.Context, e *ReplicationEvent) error { record(e.Event); return nil }).
+				OnQuota(func(ctx context.Context, e *QuotaEvent) error { record(e.Event); return nil }).
+				OnRetention(func(ctx context.Context, e *RetentionEvent) error { record(e.Event); return nil })
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, newFixtureRequest(t, tt.fixture, tt.contenttype))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+			}
+			if got != tt.wantType {
+				t.Errorf("dispatched event type = %q, want %q", got, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestHandler_ArtifactEvent(t *testing.T) {
+	for _, fixture := range []string{"push_artifact.json", "cloudevents_push_artifact.json"} {
+		t.Run(fixture, func(t *testing.T) {
+			var got *ArtifactEvent
+			h := NewHandler(testAuthHeader).OnArtifact(func(ctx context.Context, e *ArtifactEvent) error {
+				got = e
+				return nil
+			})
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, newFixtureRequest(t, fixture, "application/json"))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+			}
+			if got.Operator != "admin" {
+				t.Errorf("operator = %q", got.Operator)
+			}
+			if !got.OccurAt.Equal(time.Unix(1680501893, 0)) {
+				t.Errorf("occur_at = %s", got.OccurAt)
+			}
+			if got.Repository.RepoFullName != "library/nginx" {
+				t.Errorf("repository = %q", got.Repository.RepoFullName)
+			}
+			if len(got.Resources) != 1 || got.Resources[0].Tag != "latest" {
+				t.Errorf("resources = %+v", got.Resources)
+			}
+		})
+	}
+}
+
+func TestHandler_TypedPayloads(t *testing.T) {
+	var (
+		scan        *ScanEvent
+		replication *ReplicationEvent
+		quota       *QuotaEvent
+		retention   *RetentionEvent
+	)
+	h := NewHandler(testAuthHeader).
+		OnScan(func(ctx context.Context, e *ScanEvent) error { scan = e; return nil }).
+		OnReplication(func(ctx context.Context, e *ReplicationEvent) error { replication = e; return nil }).
+		OnQuota(func(ctx context.Context, e *QuotaEvent) error { quota = e; return nil }).
+		OnRetention(func(ctx context.Context, e *RetentionEvent) error { retention = e; return nil })
+
+	for _, fixture := range []string{"scanning_completed.json", "replication.json", "quota_exceed.json", "tag_retention.json"} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, newFixtureRequest(t, fixture, "application/json"))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, body = %s", fixture, rec.Code, rec.Body.String())
+		}
+	}
+
+	overview, ok := scan.Resources[0].ScanOverview["application/vnd.security.vulnerability.report; version=1.1"]
+	if !ok || overview.ScanStatus != "Success" || overview.Summary.Total != 37 || overview.Scanner.Name != "Trivy" {
+		t.Errorf("scan overview = %+v", overview)
+	}
+	if replication.Replication.JobStatus != "Success" || replication.Replication.SrcResource.RegistryType != "docker-hub" {
+		t.Errorf("replication = %+v", replication.Replication)
+	}
+	if quota.Details == "" || quota.Repository.Name != "nginx" {
+		t.Errorf("quota = %+v", quota)
+	}
+	if retention.Retention.Total != 3 || len(retention.Retention.DeletedArtifact) != 1 {
+		t.Errorf("retention = %+v", retention.Retention)
+	}
+}
+
+func TestHandler_CloudEventsBinaryMode(t *testing.T) {
+	var got *ArtifactEvent
+	h := NewHandler("").OnArtifact(func(ctx context.Context, e *ArtifactEvent) error {
+		got = e
+		return nil
+	})
+	body := `{"resources":[{"digest":"sha256:954b378c375d852eb3c63ab88978f640b4348b01c1b3456a024a81536dafbbf4","tag":"v1"}],"repository":{"name":"app","namespace":"demo","repo_full_name":"demo/app","repo_type":"private"}}`
+	req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Ce-Specversion", "1.0")
+	req.Header.Set("Ce-Type", "harbor.artifact.deleted")
+	req.Header.Set("Ce-Id", "1")
+	req.Header.Set("Ce-Source", "/projects/2/webhook/policies/3")
+	req.Header.Set("Ce-Time", "2023-04-03T06:04:53Z")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if got.Type != EventTypeDeleteArtifact || got.Source != "/projects/2/webhook/policies/3" || got.Repository.RepoFullName != "demo/app" {
+		t.Errorf("event = %+v", got)
+	}
+}
+
+func TestHandler_Rejects(t *testing.T) {
+	h := NewHandler(testAuthHeader)
+
+	req := newFixtureRequest(t, "push_artifact.json", "application/json")
+	req.Header.Set("Authorization", "Bearer wrong")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong auth header: status = %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewBufferString(`{"type":"UNKNOWN","occur_at":1}`))
+	req.Header.Set("Authorization", testAuthHeader)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("unknown event type: status = %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewBufferString(`{"type":"UNKNOWN","specversion":"1.0"}`))
+	req.Header.Set("Authorization", testAuthHeader)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("unknown cloudevents type: status = %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewBufferString(`{"type":`))
+	req.Header.Set("Authorization", testAuthHeader)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("malformed payload: status = %d", rec.Code)
+	}
+
+	failing := NewHandler(testAuthHeader).OnArtifact(func(ctx context.Context, e *ArtifactEvent) error {
+		return errors.New("downstream unavailable")
+	})
+	rec = httptest.NewRecorder()
+	failing.ServeHTTP(rec, newFixtureRequest(t, "push_artifact.json", "application/json"))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("callback error: status = %d", rec.Code)
+	}
+}
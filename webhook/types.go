@@ -0,0 +1,161 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/goharbor/harbor/src/pkg/scan/vuln"
+)
+
+// EventType is the event type used in harbor default webhook payload
+// https://github.com/goharbor/harbor/blob/4e1f6633afb824cd16341044a0e82f4f1f230cd2/src/controller/event/topic.go#L32
+type EventType string
+
+const (
+	EventTypePushArtifact      EventType = "PUSH_ARTIFACT"
+	EventTypePullArtifact      EventType = "PULL_ARTIFACT"
+	EventTypeDeleteArtifact    EventType = "DELETE_ARTIFACT"
+	EventTypeScanningCompleted EventType = "SCANNING_COMPLETED"
+	EventTypeScanningFailed    EventType = "SCANNING_FAILED"
+	EventTypeScanningStopped   EventType = "SCANNING_STOPPED"
+	EventTypeReplication       EventType = "REPLICATION"
+	EventTypeQuotaExceed       EventType = "QUOTA_EXCEED"
+	EventTypeQuotaWarning      EventType = "QUOTA_WARNING"
+	EventTypeTagRetention      EventType = "TAG_RETENTION"
+)
+
+// cloudEventTypes maps CloudEvents 'type' attribute send by harbor to EventType
+var cloudEventTypes = map[string]EventType{
+	"harbor.artifact.pushed":            EventTypePushArtifact,
+	"harbor.artifact.pulled":            EventTypePullArtifact,
+	"harbor.artifact.deleted":           EventTypeDeleteArtifact,
+	"harbor.scan.completed":             EventTypeScanningCompleted,
+	"harbor.scan.failed":                EventTypeScanningFailed,
+	"harbor.scan.stopped":               EventTypeScanningStopped,
+	"harbor.replication.status.changed": EventTypeReplication,
+	"harbor.quota.exceed":               EventTypeQuotaExceed,
+	"harbor.quota.warned":               EventTypeQuotaWarning,
+	"harbor.tag_retention.finished":     EventTypeTagRetention,
+}
+
+// Payload is the harbor default webhook payload
+// https://github.com/goharbor/harbor/blob/4e1f6633afb824cd16341044a0e82f4f1f230cd2/src/pkg/notifier/model/event.go#L16
+type Payload struct {
+	Type      EventType  `json:"type"`
+	OccurAt   int64      `json:"occur_at"`
+	Operator  string     `json:"operator"`
+	EventData *EventData `json:"event_data,omitempty"`
+}
+
+// EventData of a webhook event, shared by default and CloudEvents payload format
+type EventData struct {
+	Resources   []Resource        `json:"resources,omitempty"`
+	Repository  *Repository       `json:"repository,omitempty"`
+	Replication *Replication      `json:"replication,omitempty"`
+	Retention   *Retention        `json:"retention,omitempty"`
+	Custom      map[string]string `json:"custom_attributes,omitempty"`
+}
+
+type Resource struct {
+	Digest       string                              `json:"digest,omitempty"`
+	Tag          string                              `json:"tag,omitempty"`
+	ResourceURL  string                              `json:"resource_url,omitempty"`
+	ScanOverview map[string]vuln.NativeReportSummary `json:"scan_overview,omitempty"`
+}
+
+type Repository struct {
+	DateCreated  int64  `json:"date_created,omitempty"`
+	Name         string `json:"name"`
+	Namespace    string `json:"namespace"`
+	RepoFullName string `json:"repo_full_name"`
+	RepoType     string `json:"repo_type"`
+}
+
+// Replication describes replication infos
+// https://github.com/goharbor/harbor/blob/4e1f6633afb824cd16341044a0e82f4f1f230cd2/src/controller/event/model/event.go#L6
+type Replication struct {
+	HarborHostname     string               `json:"harbor_hostname,omitempty"`
+	JobStatus          string               `json:"job_status,omitempty"`
+	Description        string               `json:"description,omitempty"`
+	ArtifactType       string               `json:"artifact_type,omitempty"`
+	AuthenticationType string               `json:"authentication_type,omitempty"`
+	OverrideMode       bool                 `json:"override_mode,omitempty"`
+	TriggerType        string               `json:"trigger_type,omitempty"`
+	PolicyCreator      string               `json:"policy_creator,omitempty"`
+	ExecutionTimestamp int64                `json:"execution_timestamp,omitempty"`
+	SrcResource        *ReplicationResource `json:"src_resource,omitempty"`
+	DestResource       *ReplicationResource `json:"dest_resource,omitempty"`
+	SuccessfulArtifact []ArtifactInfo       `json:"successful_artifact,omitempty"`
+	FailedArtifact     []ArtifactInfo       `json:"failed_artifact,omitempty"`
+}
+
+// ArtifactInfo describe info of artifact
+type ArtifactInfo struct {
+	Type       string `json:"type"`
+	Status     string `json:"status"`
+	NameAndTag string `json:"name_tag"`
+	FailReason string `json:"fail_reason,omitempty"`
+}
+
+// ReplicationResource describes replication resource info
+type ReplicationResource struct {
+	RegistryName string `json:"registry_name,omitempty"`
+	RegistryType string `json:"registry_type"`
+	Endpoint     string `json:"endpoint"`
+	Provider     string `json:"provider,omitempty"`
+	Namespace    string `json:"namespace,omitempty"`
+}
+
+// Retention describes tag retention infos
+type Retention struct {
+	Total             int            `json:"total"`
+	Retained          int            `json:"retained"`
+	HarborHostname    string         `json:"harbor_hostname,omitempty"`
+	ProjectName       string         `json:"project_name,omitempty"`
+	RetentionPolicyID int64          `json:"retention_policy_id,omitempty"`
+	Status            string         `json:"result,omitempty"`
+	DeletedArtifact   []ArtifactInfo `json:"deleted_artifact,omitempty"`
+}
+
+// Event is a decoded webhook event regardless of the payload format
+type Event struct {
+	Type     EventType
+	ID       string // CloudEvents 'id' attribute,empty in default payload format
+	Source   string // CloudEvents 'source' attribute,empty in default payload format
+	OccurAt  time.Time
+	Operator string
+	Data     EventData
+}
+
+// ArtifactEvent is send on PUSH_ARTIFACT,PULL_ARTIFACT and DELETE_ARTIFACT
+type ArtifactEvent struct {
+	Event
+	Repository Repository
+	Resources  []Resource
+}
+
+// ScanEvent is send on SCANNING_COMPLETED,SCANNING_FAILED and SCANNING_STOPPED
+type ScanEvent struct {
+	Event
+	Repository Repository
+	Resources  []Resource // scan result in Resource.ScanOverview
+}
+
+// ReplicationEvent is send on REPLICATION
+type ReplicationEvent struct {
+	Event
+	Replication Replication
+}
+
+// QuotaEvent is send on QUOTA_EXCEED and QUOTA_WARNING
+type QuotaEvent struct {
+	Event
+	Repository Repository
+	Resources  []Resource
+	Details    string // the quota usage message, e.g. "adding 1.2 MiB of storage resource, which when updated to current usage of ..."
+}
+
+// RetentionEvent is send on TAG_RETENTION
+type RetentionEvent struct {
+	Event
+	Retention Retention
+}